@@ -6,51 +6,98 @@ import (
 	"fmt"
 	"log"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
 
+// lastReportLatency is the time in milliseconds spent by the reporter for the previous invocation
+var lastReportLatency int64
+
+var prewarmOnce sync.Once
+
+var logSettingsOnce sync.Once
+
 // Agent is thundra agent implementation
 type Agent struct {
 	Plugins       []plugin.Plugin
-	Reporter      reporter
+	Reporter      Reporter
 	WarmUp        bool
 	TimeoutMargin time.Duration
+	extension     *extension
+
+	// Set by the options
+	overrides     []func(*config.Settings)
+	samplers      map[string]samplers.Sampler
+	spanListeners []tracer.ThundraSpanListener
 }
 
 // New is used to collect basic invocation data with thundra. Use NewBuilder and AddPlugin to access full functionality.
-func New() *Agent {
-	return &Agent{
-		Reporter:      newReporter(),
-		WarmUp:        config.WarmupEnabled,
-		TimeoutMargin: config.TimeoutMargin,
-		Plugins:       []plugin.Plugin{},
+// The settings are read from the environment unless they are given with options.
+// The remote config is fetched here for the first time if it is enabled.
+func New(options ...Option) *Agent {
+	config.PollRemoteConfig()
+	if config.DebugEnabled {
+		logSettingsOnce.Do(config.LogSettings)
+	}
+	a := &Agent{
+		Plugins: []plugin.Plugin{},
 	}
+	for _, option := range options {
+		option(a)
+	}
+	s := a.invocationSettings()
+	if s.ReportFileEnabled {
+		a.Reporter = NewRotatingFileReporter(s.ReportFileDir, int64(s.ReportFileMaxSize), s.ReportFileMaxInvocations)
+	} else {
+		a.Reporter = newReporter()
+	}
+	a.WarmUp = s.WarmupEnabled
+	a.TimeoutMargin = s.TimeoutMargin
+	// The extension has to be registered during the init phase of the function. It is not registered
+	// if the agent is disabled since the handler is not wrapped and nothing would be reported to it.
+	if s.ExtensionEnabled && !s.ThundraDisabled {
+		a.extension = getExtension()
+	}
+	if s.ReportRestPrewarmEnabled && !s.ReportCloudwatchEnabled && !s.ReportFileEnabled {
+		prewarmOnce.Do(func() {
+			if client := getHTTPClient(s); client != nil {
+				prewarmHTTPClient(client, s.CollectorUrl)
+			}
+		})
+	}
+	return a
 }
 
 // AddPlugin is used to enable plugins on thundra. You can use Trace, Metrics and Log plugins.
 // You need to initialize a plugin object and pass it as a parameter in order to enable it.
 // e.g. AddPlugin(trace.New())
-func (a *Agent) AddPlugin(plugin plugin.Plugin) *Agent {
-	if plugin.IsEnabled() {
-		a.Plugins = append(a.Plugins, plugin)
+func (a *Agent) AddPlugin(p plugin.Plugin) *Agent {
+	// Plugins are enabled by the settings of the agent
+	if plugin.IsEnabledWith(p, a.invocationSettings()) {
+		a.Plugins = append(a.Plugins, p)
 	}
 
 	return a
 }
 
-// SetReporter sets agent reporter
-func (a *Agent) SetReporter(r reporter) *Agent {
+// SetReporter sets agent reporter. Use NewFanOutReporter to report to several destinations.
+func (a *Agent) SetReporter(r Reporter) *Agent {
 	a.Reporter = r
 	return a
 }
 
-// ExecutePreHooks contains necessary works that should be done before user's handler
+// ExecutePreHooks contains necessary works that should be done before user's handler.
+// The returned context carries the settings of the agent for the plugins and the integrations.
 func (a *Agent) ExecutePreHooks(ctx context.Context, request json.RawMessage) context.Context {
+	ctx = a.invocationContext(ctx)
 	a.Reporter.FlushFlag()
 
 	// Sort plugins w.r.t their orders
@@ -59,6 +106,9 @@ func (a *Agent) ExecutePreHooks(ctx context.Context, request json.RawMessage) co
 	})
 	plugin.TraceID = utils.GenerateNewID()
 	plugin.TransactionID = utils.GenerateNewID()
+	plugin.DroppedBatchCount = atomic.LoadUint64(&droppedBatches)
+	plugin.LastReportLatency = atomic.LoadInt64(&lastReportLatency)
+	plugin.ReporterTelemetry = reporterTelemetry()
 
 	// Traverse sorted plugin slice
 	for _, p := range a.Plugins {
@@ -74,15 +124,62 @@ func (a *Agent) ExecutePostHooks(ctx context.Context, request json.RawMessage, r
 	if *a.Reporter.Reported() == 1 {
 		return
 	}
+	setReportDeadline(ctx)
+	s := config.SettingsFromContext(ctx)
 	// Traverse the plugin slice in reverse order
-	var messages []plugin.MonitoringDataWrapper
+	var messages, allMessages []plugin.MonitoringDataWrapper
 	for i := len(a.Plugins) - 1; i >= 0; i-- {
 		p := a.Plugins[i]
 		messages, ctx = p.AfterExecution(ctx, request, response, err)
-		a.Reporter.Collect(messages)
+		allMessages = append(allMessages, messages...)
+		messages = reportedMessages(messages)
+		collectWith(a.Reporter, s, messages)
+		recordCollected(len(messages))
+	}
+	report := func() {
+		// StatsD is an additional sink, the data is reported as usual
+		if s.ReportStatsDEnabled {
+			sendStatsD(s, allMessages)
+		}
+		start := time.Now()
+		reportWith(a.Reporter, s)
+		atomic.StoreInt64(&lastReportLatency, int64(time.Since(start)/time.Millisecond))
+		a.Reporter.ClearData()
+		// Send the batches which could not be sent in the previous invocations
+		// without delaying the handler of the next invocation
+		if sr, ok := a.Reporter.(spoolReplayer); ok {
+			sr.replaySpool(s)
+		}
+		// The remote config is fetched after the data is reported so that it does not delay the handler.
+		// The new settings are used from the next invocation on.
+		config.PollRemoteConfig()
+	}
+	if a.extension != nil {
+		// The extension reports the data after the response is sent
+		atomic.StoreUint32(a.Reporter.Reported(), 1)
+		a.extension.report(report)
+		return
+	}
+	report()
+}
+
+// skipReport lets the extension go on with the next invocation when the handler returns before the hooks
+// are executed, e.g. for warmup requests. Otherwise the extension waits for the report until the deadline.
+func (a *Agent) skipReport() {
+	if a.extension != nil {
+		a.extension.report(func() {})
 	}
-	a.Reporter.Report()
-	a.Reporter.ClearData()
+}
+
+// reportedMessages returns the messages which are not only sent to the local sinks
+func reportedMessages(messages []plugin.MonitoringDataWrapper) []plugin.MonitoringDataWrapper {
+	reported := messages[:0:0]
+	for _, message := range messages {
+		if !message.LocalOnly {
+			reported = append(reported, message)
+		}
+	}
+	return reported
 }
 
 // CatchTimeout is checks for a timeout event and sends report if lambda is timedout
@@ -112,6 +209,11 @@ func (a *Agent) CatchTimeout(ctx context.Context, payload json.RawMessage) {
 	}
 }
 
+// spoolReplayer is implemented by the reporters which send the spooled collector batches
+type spoolReplayer interface {
+	replaySpool(s *config.Settings)
+}
+
 type timeoutError struct{}
 
 func (e timeoutError) Error() string {
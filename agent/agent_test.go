@@ -47,7 +47,9 @@ func TestExecutePreHooks(t *testing.T) {
 	ctx := context.TODO()
 	req := createRawMessage()
 
-	mT.On("BeforeExecution", ctx, req, mock.Anything, mock.Anything).Return()
+	// The plugins get the context carrying the settings of the agent
+	invocationCtx := mock.MatchedBy(func(c context.Context) bool { return c.Value(agentKey{}) == th })
+	mT.On("BeforeExecution", invocationCtx, req, mock.Anything, mock.Anything).Return()
 	th.ExecutePreHooks(ctx, req)
 	mT.AssertExpectations(t)
 }
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// truncatedValueLength is the length the string tag values are truncated to when a message is too large
+const truncatedValueLength = 1024
+const truncatedValueSuffix = "...<truncated>"
+
+var trimmedTagFields = []string{"tags", "userTags"}
+
+// batchMessages splits the messages into batches of at most maxCount messages whose total serialized
+// size fits in maxBytes including the given overhead of the batch envelope. The tags of the messages
+// which do not fit in a batch on their own are trimmed and the messages are dropped if they still
+// do not fit. If maxBytes is not positive, the messages are split only by count.
+func batchMessages(messages []plugin.MonitoringDataWrapper, maxCount int, maxBytes int, overhead int, composite bool) [][]plugin.MonitoringDataWrapper {
+	var batches [][]plugin.MonitoringDataWrapper
+	var batch []plugin.MonitoringDataWrapper
+	budget := maxBytes - overhead
+	size := 0
+	for _, message := range messages {
+		messageSize := 0
+		if maxBytes > 0 {
+			var ok bool
+			if message, messageSize, ok = fitMessage(message, budget, composite); !ok {
+				continue
+			}
+		}
+		if len(batch) > 0 && ((maxCount > 0 && len(batch) >= maxCount) || (maxBytes > 0 && size+messageSize > budget)) {
+			batches = append(batches, batch)
+			batch = nil
+			size = 0
+		}
+		batch = append(batch, message)
+		// Messages are separated by commas in the batch
+		size += messageSize + 1
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// batchConverted splits the data converted for another protocol into batches like batchMessages by the
+// batch size and the max bytes of the REST reporter. The overhead is the size of a request without any data.
+func batchConverted(s *config.Settings, dataType string, data []interface{}, overhead int) [][]interface{} {
+	messages := make([]plugin.MonitoringDataWrapper, len(data))
+	for i := range data {
+		messages[i] = plugin.MonitoringDataWrapper{Type: dataType, Data: data[i]}
+	}
+	var batches [][]interface{}
+	for _, messageBatch := range batchMessages(messages, s.ReportRestCompositeBatchSize, s.ReportRestMaxBytes, overhead, true) {
+		batch := make([]interface{}, len(messageBatch))
+		for i := range messageBatch {
+			batch[i] = messageBatch[i].Data
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// jsonSize returns the serialized size of v
+func jsonSize(v interface{}) int {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// fitMessages trims the messages exceeding maxBytes and drops the ones which still do not fit
+func fitMessages(messages []plugin.MonitoringDataWrapper, maxBytes int) []plugin.MonitoringDataWrapper {
+	if maxBytes <= 0 {
+		return messages
+	}
+	fitted := make([]plugin.MonitoringDataWrapper, 0, len(messages))
+	for _, message := range messages {
+		if message, _, ok := fitMessage(message, maxBytes, false); ok {
+			fitted = append(fitted, message)
+		}
+	}
+	return fitted
+}
+
+// compositeOverhead returns the serialized size of a composite data without any monitoring data
+func compositeOverhead() int {
+	compositeData := plugin.PrepareCompositeData(plugin.PrepareBaseData(), nil)
+	b, err := json.Marshal(plugin.WrapMonitoringData(compositeData, "Composite"))
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+func marshalMessage(message plugin.MonitoringDataWrapper, composite bool) ([]byte, error) {
+	// Only the data of the messages are put in composite data
+	if composite {
+		return json.Marshal(message.Data)
+	}
+	return json.Marshal(message)
+}
+
+// fitMessage returns the message and its size. If the message exceeds maxSize, its largest
+// tags are truncated until it fits. It returns false if the message can not be fitted.
+func fitMessage(message plugin.MonitoringDataWrapper, maxSize int, composite bool) (plugin.MonitoringDataWrapper, int, bool) {
+	b, err := marshalMessage(message, composite)
+	if err != nil {
+		recordSerializationError()
+		log.Println("Error in marshalling ", err)
+		return message, 0, false
+	}
+	if len(b) <= maxSize {
+		return message, len(b), true
+	}
+
+	b, err = json.Marshal(message.Data)
+	if err != nil {
+		recordSerializationError()
+		log.Println("Error in marshalling ", err)
+		return message, 0, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		recordDroppedMessage()
+		log.Printf("%s data with size %d exceeds the max size %d and can not be trimmed\n", message.Type, len(b), maxSize)
+		return message, 0, false
+	}
+
+	type tagSize struct {
+		field string
+		key   string
+		size  int
+	}
+	var tagSizes []tagSize
+	for _, field := range trimmedTagFields {
+		tags, _ := data[field].(map[string]interface{})
+		for k, v := range tags {
+			vb, _ := json.Marshal(v)
+			if len(vb) > truncatedValueLength {
+				tagSizes = append(tagSizes, tagSize{field, k, len(vb)})
+			}
+		}
+	}
+	sort.Slice(tagSizes, func(i, j int) bool {
+		return tagSizes[i].size > tagSizes[j].size
+	})
+
+	var truncatedTags []string
+	for _, ts := range tagSizes {
+		tags := data[ts.field].(map[string]interface{})
+		if s, ok := tags[ts.key].(string); ok && len(s) > truncatedValueLength {
+			tags[ts.key] = truncateString(s, truncatedValueLength) + truncatedValueSuffix
+		} else {
+			tags[ts.key] = truncatedValueSuffix
+		}
+		truncatedTags = append(truncatedTags, ts.key)
+
+		markTruncated(data, truncatedTags)
+		message.Data = data
+		if b, err = marshalMessage(message, composite); err == nil && len(b) <= maxSize {
+			return message, len(b), true
+		}
+	}
+	recordDroppedMessage()
+	log.Printf("%s data with size %d exceeds the max size %d, it is dropped\n", message.Type, len(b), maxSize)
+	return message, 0, false
+}
+
+// truncateString returns at most n bytes of s without splitting a UTF-8 character
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// markTruncated records the truncated tags in the tags of the data
+func markTruncated(data map[string]interface{}, truncatedTags []string) {
+	tags, ok := data["tags"].(map[string]interface{})
+	if !ok {
+		tags = map[string]interface{}{}
+		data["tags"] = tags
+	}
+	tags[constants.ThundraAgentTruncatedTags] = truncatedTags
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func spanWithTag(value string) plugin.MonitoringDataWrapper {
+	return plugin.WrapMonitoringData(map[string]interface{}{
+		"id":   "span-id",
+		"tags": map[string]interface{}{"http.body": value, "http.method": "GET"},
+	}, "Span")
+}
+
+func TestBatchMessagesBySize(t *testing.T) {
+	messages := []plugin.MonitoringDataWrapper{
+		spanWithTag(strings.Repeat("a", 400)),
+		spanWithTag(strings.Repeat("b", 400)),
+		spanWithTag(strings.Repeat("c", 400)),
+	}
+	batches := batchMessages(messages, 100, 1200, 100, true)
+
+	assert.Equal(t, 2, len(batches))
+	assert.Equal(t, messages[:2], batches[0])
+	assert.Equal(t, messages[2:], batches[1])
+}
+
+func TestBatchMessagesByCount(t *testing.T) {
+	messages := []plugin.MonitoringDataWrapper{spanWithTag("a"), spanWithTag("b"), spanWithTag("c")}
+
+	assert.Equal(t, 2, len(batchMessages(messages, 2, 1024*1024, 100, true)))
+	assert.Equal(t, 3, len(batchMessages(messages, 1, 0, 0, true)))
+}
+
+func TestBatchMessagesTruncatesOversizedTags(t *testing.T) {
+	messages := []plugin.MonitoringDataWrapper{spanWithTag(strings.Repeat("a", 5000))}
+	batches := batchMessages(messages, 100, 2000, 100, true)
+
+	assert.Equal(t, 1, len(batches))
+	b, _ := json.Marshal(batches[0][0].Data)
+	assert.True(t, len(b) <= 1900)
+
+	data := batches[0][0].Data.(map[string]interface{})
+	tags := data["tags"].(map[string]interface{})
+	assert.Equal(t, strings.Repeat("a", truncatedValueLength)+truncatedValueSuffix, tags["http.body"])
+	assert.Equal(t, "GET", tags["http.method"])
+	assert.Equal(t, []string{"http.body"}, tags[constants.ThundraAgentTruncatedTags])
+}
+
+func TestBatchMessagesDropsMessagesWhichDoNotFit(t *testing.T) {
+	huge := plugin.WrapMonitoringData(map[string]interface{}{"id": strings.Repeat("a", 5000)}, "Span")
+	batches := batchMessages([]plugin.MonitoringDataWrapper{huge, spanWithTag("a")}, 100, 2000, 100, true)
+
+	assert.Equal(t, [][]plugin.MonitoringDataWrapper{{spanWithTag("a")}}, batches)
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "abc", truncateString("abc", 5))
+	assert.Equal(t, "ab", truncateString("abc", 2))
+	assert.Equal(t, "a", truncateString("aç", 2))
+}
+
+func TestReportSplitsBatchesBySize(t *testing.T) {
+	config.ReportRestMaxBytes = 4096
+	defer func() { config.ReportRestMaxBytes = constants.ThundraLambdaReportRestMaxBytesDefault }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var lock sync.Mutex
+	var sizes []int
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		lock.Lock()
+		sizes = append(sizes, len(body))
+		lock.Unlock()
+		return &(http.Response{}), nil
+	})
+	for i := 0; i < 10; i++ {
+		testReporter.messageQueue = append(testReporter.messageQueue, spanWithTag(strings.Repeat("a", 1000)))
+	}
+	testReporter.Report()
+
+	assert.True(t, len(sizes) > 1)
+	for _, size := range sizes {
+		assert.True(t, size <= 4096)
+	}
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// shouldCompress returns true if compression is enabled and the payload is not smaller than the threshold
+func shouldCompress(s *config.Settings, size int) bool {
+	return s.ReportCompressionEnabled && size >= s.ReportCompressionThreshold
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compressMonitoringData replaces the data of the wrapper with its gzipped and base64 encoded JSON
+// and sets the Compressed flag
+func compressMonitoringData(wrapper plugin.MonitoringDataWrapper) (plugin.MonitoringDataWrapper, error) {
+	b, err := json.Marshal(wrapper.Data)
+	if err != nil {
+		return wrapper, err
+	}
+	gz, err := gzipBytes(b)
+	if err != nil {
+		return wrapper, err
+	}
+	wrapper.Data = base64.StdEncoding.EncodeToString(gz)
+	wrapper.Compressed = true
+	return wrapper, nil
+}
+
+// marshalAsync marshals the wrapper to be written to stdout. The data is compressed
+// if the marshalled wrapper exceeds the compression threshold.
+func marshalAsync(s *config.Settings, wrapper plugin.MonitoringDataWrapper) ([]byte, error) {
+	b, err := json.Marshal(wrapper)
+	if err != nil || !shouldCompress(s, len(b)) {
+		return b, err
+	}
+	compressed, err := compressMonitoringData(wrapper)
+	if err != nil {
+		log.Println("Error in compressing monitoring data:", err)
+		return b, nil
+	}
+	return json.Marshal(compressed)
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func gunzip(t *testing.T, b []byte) []byte {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	return data
+}
+
+func enableCompression(threshold int) func() {
+	config.ReportCompressionEnabled = true
+	config.ReportCompressionThreshold = threshold
+	return func() {
+		config.ReportCompressionEnabled = false
+		config.ReportCompressionThreshold = 1024
+	}
+}
+
+func TestReportCompressed(t *testing.T) {
+	defer enableCompression(0)()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	messages := []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")}
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+		body, _ := ioutil.ReadAll(req.Body)
+		var data plugin.MonitoringDataWrapper
+		assert.Nil(t, json.Unmarshal(gunzip(t, body), &data))
+		assert.Equal(t, "Composite", data.Type)
+		assert.False(t, data.Compressed)
+		return &(http.Response{}), nil
+	})
+	testReporter.messageQueue = messages
+	testReporter.Report()
+}
+
+func TestReportBelowCompressionThreshold(t *testing.T) {
+	defer enableCompression(1024 * 1024)()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	messages := []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")}
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "", req.Header.Get("Content-Encoding"))
+		body, _ := ioutil.ReadAll(req.Body)
+		var data plugin.MonitoringDataWrapper
+		assert.Nil(t, json.Unmarshal(body, &data))
+		return &(http.Response{}), nil
+	})
+	testReporter.messageQueue = messages
+	testReporter.Report()
+}
+
+func TestMarshalAsyncCompressed(t *testing.T) {
+	defer enableCompression(0)()
+
+	wrapper := plugin.WrapMonitoringData(map[string]interface{}{"id": "span-id"}, "Span")
+	b, err := marshalAsync(currentSettings(), wrapper)
+	assert.Nil(t, err)
+
+	var compressed plugin.MonitoringDataWrapper
+	assert.Nil(t, json.Unmarshal(b, &compressed))
+	assert.True(t, compressed.Compressed)
+	assert.Equal(t, "Span", compressed.Type)
+
+	gz, err := base64.StdEncoding.DecodeString(compressed.Data.(string))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"span-id"}`, string(gunzip(t, gz)))
+}
+
+func TestMarshalAsyncCompressionDisabled(t *testing.T) {
+	wrapper := plugin.WrapMonitoringData(map[string]interface{}{"id": "span-id"}, "Span")
+	b, err := marshalAsync(currentSettings(), wrapper)
+	assert.Nil(t, err)
+
+	var data plugin.MonitoringDataWrapper
+	assert.Nil(t, json.Unmarshal(b, &data))
+	assert.False(t, data.Compressed)
+	assert.Equal(t, map[string]interface{}{"id": "span-id"}, data.Data)
+}
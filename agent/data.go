@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const (
+	spanDataType       = "Span"
+	invocationDataType = "Invocation"
+	metricDataType     = "Metric"
+)
+
+// monitoringData holds the fields of the span, invocation and metric data models
+// which are used when the data is converted to the formats of other backends
+type monitoringData struct {
+	ID              string                 `json:"id"`
+	TraceID         string                 `json:"traceId"`
+	TransactionID   string                 `json:"transactionId"`
+	SpanID          string                 `json:"spanId"`
+	ParentSpanID    string                 `json:"parentSpanId"`
+	DomainName      string                 `json:"domainName"`
+	ClassName       string                 `json:"className"`
+	OperationName   string                 `json:"operationName"`
+	StartTimestamp  int64                  `json:"startTimestamp"`
+	FinishTimestamp int64                  `json:"finishTimestamp"`
+	Duration        int64                  `json:"duration"`
+	Erroneous       bool                   `json:"erroneous"`
+	ErrorType       string                 `json:"errorType"`
+	ErrorMessage    string                 `json:"errorMessage"`
+	ColdStart       bool                   `json:"coldStart"`
+	Timeout         bool                   `json:"timeout"`
+	MetricName      string                 `json:"metricName"`
+	MetricTimestamp int64                  `json:"metricTimestamp"`
+	Metrics         map[string]interface{} `json:"metrics"`
+	Tags            map[string]interface{} `json:"tags"`
+	UserTags        map[string]interface{} `json:"userTags"`
+	Logs            map[string]spanLogData `json:"logs"`
+}
+
+type spanLogData struct {
+	Name      string      `json:"name"`
+	Value     interface{} `json:"value"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// decodeMonitoringData reads the fields of the given data model through its JSON form
+func decodeMonitoringData(data interface{}) (monitoringData, error) {
+	md := monitoringData{}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return md, err
+	}
+	// Keep numbers as they are so that integer tags and metrics stay integers
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	err = d.Decode(&md)
+	return md, err
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+)
+
+// Please visit https://docs.aws.amazon.com/lambda/latest/dg/runtimes-extensions-api.html to learn more about extensions.
+
+const extensionName = "thundra-lambda-agent-go"
+const extensionAPIVersion = "2020-01-01"
+
+const (
+	extensionInvokeEvent   = "INVOKE"
+	extensionShutdownEvent = "SHUTDOWN"
+)
+
+// extensionEvent is the response of the next event request
+type extensionEvent struct {
+	EventType      string `json:"eventType"`
+	DeadlineMs     int64  `json:"deadlineMs"`
+	RequestID      string `json:"requestId"`
+	ShutdownReason string `json:"shutdownReason"`
+}
+
+// extension registers the agent as an internal Lambda extension so that the data of an invocation
+// is reported after the response is sent. Lambda does not start the next invocation before the
+// extension asks for the next event, so reporting does not overlap with the next invocation.
+type extension struct {
+	client  *http.Client
+	baseURL string
+	id      string
+	reports chan func()
+	done    chan struct{}
+}
+
+// newExtension registers an internal extension through the Extensions API at the given address.
+// It must be called during the init phase of the function.
+func newExtension(runtimeAPI string) (*extension, error) {
+	e := &extension{
+		client:  &http.Client{},
+		baseURL: "http://" + runtimeAPI + "/" + extensionAPIVersion + "/extension",
+		reports: make(chan func(), 1),
+		done:    make(chan struct{}),
+	}
+	body, _ := json.Marshal(map[string][]string{"events": {extensionInvokeEvent, extensionShutdownEvent}})
+	req, err := http.NewRequest("POST", e.baseURL+"/register", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Lambda-Extension-Name", extensionName)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extension register request failed with %s", resp.Status)
+	}
+	e.id = resp.Header.Get("Lambda-Extension-Identifier")
+	return e, nil
+}
+
+var lambdaExtension *extension
+var extensionOnce sync.Once
+
+// getExtension registers the extension once and returns it. It returns nil if the extension
+// can not be registered, so that the data is reported synchronously.
+func getExtension() *extension {
+	extensionOnce.Do(func() {
+		lambdaExtension = startExtension()
+	})
+	return lambdaExtension
+}
+
+// startExtension registers the extension and starts processing the events
+func startExtension() *extension {
+	runtimeAPI := config.AwsLambdaRuntimeAPI
+	if runtimeAPI == "" {
+		log.Println("Lambda runtime API address is not set, the data will be reported before the response")
+		return nil
+	}
+	e, err := newExtension(runtimeAPI)
+	if err != nil {
+		log.Println("Error while registering the Lambda extension, the data will be reported before the response:", err)
+		return nil
+	}
+	go e.run()
+	return e
+}
+
+// report hands the report of the finished invocation over to the extension
+func (e *extension) report(report func()) {
+	select {
+	case <-e.done:
+		// The extension is not running anymore
+		report()
+		return
+	default:
+	}
+	select {
+	case e.reports <- report:
+	default:
+		// A report is already pending, do not block the invocation
+		report()
+	}
+}
+
+func (e *extension) run() {
+	defer close(e.done)
+	for {
+		event, err := e.next()
+		if err != nil {
+			log.Println("Error while getting the next Lambda extension event:", err)
+			e.reportPending()
+			return
+		}
+		switch event.EventType {
+		case extensionInvokeEvent:
+			e.waitAndReport(time.Unix(0, event.DeadlineMs*int64(time.Millisecond)))
+		case extensionShutdownEvent:
+			if config.DebugEnabled {
+				log.Println("Lambda extension is shutting down:", event.ShutdownReason)
+			}
+			e.reportPending()
+			return
+		}
+	}
+}
+
+// waitAndReport waits for the report of the current invocation until the deadline and runs it
+func (e *extension) waitAndReport(deadline time.Time) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case report := <-e.reports:
+		report()
+	case <-timer.C:
+		log.Println("Invocation data is not ready before the deadline")
+	}
+}
+
+func (e *extension) reportPending() {
+	for {
+		select {
+		case report := <-e.reports:
+			report()
+		default:
+			return
+		}
+	}
+}
+
+func (e *extension) next() (*extensionEvent, error) {
+	req, err := http.NewRequest("GET", e.baseURL+"/event/next", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Lambda-Extension-Identifier", e.id)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("next event request failed with %s", resp.Status)
+	}
+	var event extensionEvent
+	if err := json.NewDecoder(resp.Body).Decode(&event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
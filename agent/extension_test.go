@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+// extensionsAPI is a local stand-in for the Lambda Extensions API
+type extensionsAPI struct {
+	server *httptest.Server
+	events chan extensionEvent
+	body   string
+}
+
+func newExtensionsAPI(t *testing.T) *extensionsAPI {
+	api := &extensionsAPI{events: make(chan extensionEvent)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2020-01-01/extension/register", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, extensionName, r.Header.Get("Lambda-Extension-Name"))
+		body, _ := ioutil.ReadAll(r.Body)
+		api.body = string(body)
+		w.Header().Set("Lambda-Extension-Identifier", "extension-id")
+	})
+	mux.HandleFunc("/2020-01-01/extension/event/next", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "extension-id", r.Header.Get("Lambda-Extension-Identifier"))
+		json.NewEncoder(w).Encode(<-api.events)
+	})
+	api.server = httptest.NewServer(mux)
+	return api
+}
+
+func (api *extensionsAPI) address() string {
+	return strings.TrimPrefix(api.server.URL, "http://")
+}
+
+func (api *extensionsAPI) invoke() {
+	api.events <- extensionEvent{
+		EventType:  extensionInvokeEvent,
+		DeadlineMs: time.Now().Add(time.Second).UnixNano() / int64(time.Millisecond),
+		RequestID:  "request-id",
+	}
+}
+
+func waitReported(t *testing.T, reported chan string) string {
+	select {
+	case r := <-reported:
+		return r
+	case <-time.After(time.Second):
+		assert.Fail(t, "data is not reported")
+		return ""
+	}
+}
+
+func TestExtensionRegister(t *testing.T) {
+	api := newExtensionsAPI(t)
+	defer api.server.Close()
+
+	e, err := newExtension(api.address())
+	assert.Nil(t, err)
+	assert.Equal(t, "extension-id", e.id)
+	assert.JSONEq(t, `{"events":["INVOKE","SHUTDOWN"]}`, api.body)
+}
+
+func TestExtensionReportsOnInvokeAndShutdown(t *testing.T) {
+	api := newExtensionsAPI(t)
+	defer api.server.Close()
+	e, err := newExtension(api.address())
+	assert.Nil(t, err)
+	go e.run()
+
+	reported := make(chan string, 2)
+	e.report(func() { reported <- "first" })
+	api.invoke()
+	assert.Equal(t, "first", waitReported(t, reported))
+
+	e.report(func() { reported <- "second" })
+	api.events <- extensionEvent{EventType: extensionShutdownEvent, ShutdownReason: "spindown"}
+	assert.Equal(t, "second", waitReported(t, reported))
+
+	<-e.done
+	e.report(func() { reported <- "after shutdown" })
+	assert.Equal(t, "after shutdown", waitReported(t, reported))
+}
+
+func TestExtensionRegisterFails(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := newExtension(strings.TrimPrefix(server.URL, "http://"))
+	assert.NotNil(t, err)
+}
+
+func TestExecutePostHooksWithExtension(t *testing.T) {
+	api := newExtensionsAPI(t)
+	defer api.server.Close()
+	e, err := newExtension(api.address())
+	assert.Nil(t, err)
+	go e.run()
+	defer func() { api.events <- extensionEvent{EventType: extensionShutdownEvent} }()
+
+	r := test.NewMockReporter()
+	var reports int32
+	r.ExpectedCalls = nil
+	r.On("ClearData").Return()
+	r.On("Report").Return().Run(func(_ mock.Arguments) { atomic.AddInt32(&reports, 1) })
+	a := New().SetReporter(r)
+	a.extension = e
+
+	a.ExecutePostHooks(context.TODO(), nil, nil, nil)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(r.Reported()))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&reports))
+
+	api.invoke()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&reports) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestExtensionDoesNotWaitWhenHooksAreSkipped(t *testing.T) {
+	api := newExtensionsAPI(t)
+	defer api.server.Close()
+	e, err := newExtension(api.address())
+	assert.Nil(t, err)
+	go e.run()
+
+	a := New().SetReporter(test.NewMockReporter())
+	a.WarmUp = true
+	a.extension = e
+	handler := a.Wrap(func(ctx context.Context, event struct{ Name string }) error {
+		return nil
+	}).(func(context.Context, json.RawMessage) (interface{}, error))
+	invalidHandler := a.Wrap("not a function").(lambdaFunction)
+
+	invokeUntil := func(deadline time.Time) {
+		select {
+		case api.events <- extensionEvent{EventType: extensionInvokeEvent, DeadlineMs: deadline.UnixNano() / int64(time.Millisecond)}:
+		case <-time.After(time.Second):
+			assert.Fail(t, "extension waits for the report of the previous invocation")
+		}
+	}
+	deadline := time.Now().Add(time.Minute)
+
+	// Warmup request
+	invokeUntil(deadline)
+	_, err = handler(context.TODO(), json.RawMessage(`"#warmup"`))
+	assert.Nil(t, err)
+
+	// Payload which can not be unmarshalled
+	invokeUntil(deadline)
+	_, err = handler(context.TODO(), json.RawMessage(`[]`))
+	assert.NotNil(t, err)
+
+	// Invalid handler
+	invokeUntil(deadline)
+	_, err = invalidHandler(context.TODO(), json.RawMessage(`{}`))
+	assert.NotNil(t, err)
+
+	select {
+	case api.events <- extensionEvent{EventType: extensionShutdownEvent}:
+	case <-time.After(time.Second):
+		assert.Fail(t, "extension waits for the report of the previous invocation")
+	}
+	<-e.done
+}
+
+func TestExtensionIsNotRegisteredWhenDisabled(t *testing.T) {
+	a := New(WithSettings(func(s *config.Settings) {
+		s.ThundraDisabled = true
+		s.ExtensionEnabled = true
+	}))
+	assert.Nil(t, a.extension)
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// fanOutReporter passes the data to several reporters. A failing reporter
+// does not prevent the others from reporting.
+type fanOutReporter struct {
+	reporters []Reporter
+	reported  *uint32
+}
+
+// NewFanOutReporter returns a Reporter which reports the data to all of the given reporters.
+// The reporters report concurrently and a panic in one of them is recovered and logged.
+func NewFanOutReporter(reporters ...Reporter) Reporter {
+	return &fanOutReporter{
+		reporters: reporters,
+		reported:  new(uint32),
+	}
+}
+
+// Collect passes the data to all reporters
+func (r *fanOutReporter) Collect(messages []plugin.MonitoringDataWrapper) {
+	r.collectWith(currentSettings(), messages)
+}
+
+func (r *fanOutReporter) collectWith(s *config.Settings, messages []plugin.MonitoringDataWrapper) {
+	for _, reporter := range r.reporters {
+		safeCall(func() { collectWith(reporter, s, messages) })
+	}
+}
+
+// Report makes all reporters report their data and waits for them to finish
+func (r *fanOutReporter) Report() {
+	r.reportWith(currentSettings())
+}
+
+func (r *fanOutReporter) reportWith(s *config.Settings) {
+	atomic.CompareAndSwapUint32(r.reported, 0, 1)
+	var wg sync.WaitGroup
+	for _, reporter := range r.reporters {
+		wg.Add(1)
+		go func(reporter Reporter) {
+			defer wg.Done()
+			safeCall(func() { reportWith(reporter, s) })
+		}(reporter)
+	}
+	wg.Wait()
+}
+
+// ClearData clears the data of all reporters
+func (r *fanOutReporter) ClearData() {
+	for _, reporter := range r.reporters {
+		safeCall(reporter.ClearData)
+	}
+}
+
+// Reported returns reported
+func (r *fanOutReporter) Reported() *uint32 {
+	return r.reported
+}
+
+// FlushFlag flushes the reported flags of the fan-out reporter and all reporters
+func (r *fanOutReporter) FlushFlag() {
+	atomic.CompareAndSwapUint32(r.reported, 1, 0)
+	for _, reporter := range r.reporters {
+		safeCall(reporter.FlushFlag)
+	}
+}
+
+func (r *fanOutReporter) replaySpool(s *config.Settings) {
+	for _, reporter := range r.reporters {
+		if sr, ok := reporter.(spoolReplayer); ok {
+			safeCall(func() { sr.replaySpool(s) })
+		}
+	}
+}
+
+func safeCall(f func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("Error in reporter:", err)
+		}
+	}()
+	f()
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+type panickingReporter struct {
+	*test.MockReporter
+}
+
+func (r *panickingReporter) Report() {
+	panic("sink is down")
+}
+
+func TestFanOutReporter(t *testing.T) {
+	first := test.NewMockReporter()
+	second := test.NewMockReporter()
+	r := NewFanOutReporter(first, second)
+
+	messages := []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")}
+	r.Collect(messages)
+	r.Report()
+
+	for _, sink := range []*test.MockReporter{first, second} {
+		assert.Equal(t, messages, sink.MessageQueue)
+		sink.AssertCalled(t, "Report")
+		assert.Equal(t, uint32(1), atomic.LoadUint32(sink.Reported()))
+	}
+	assert.Equal(t, uint32(1), atomic.LoadUint32(r.Reported()))
+
+	r.ClearData()
+	r.FlushFlag()
+	first.AssertCalled(t, "ClearData")
+	second.AssertCalled(t, "ClearData")
+	assert.Equal(t, uint32(0), atomic.LoadUint32(r.Reported()))
+	assert.Equal(t, uint32(0), atomic.LoadUint32(first.Reported()))
+}
+
+func TestFanOutReporterIsolatesFailingSink(t *testing.T) {
+	failing := &panickingReporter{test.NewMockReporter()}
+	healthy := test.NewMockReporter()
+	r := NewFanOutReporter(failing, healthy)
+
+	r.Collect([]plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")})
+	assert.NotPanics(t, r.Report)
+
+	healthy.AssertCalled(t, "Report")
+	assert.Equal(t, uint32(1), atomic.LoadUint32(healthy.Reported()))
+}
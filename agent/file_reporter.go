@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// fileReporter appends the data to a file as newline delimited JSON,
+// one monitoring data per line
+type fileReporter struct {
+	path         string
+	lock         sync.Mutex
+	messageQueue []plugin.MonitoringDataWrapper
+	reported     *uint32
+
+	// Rotation is enabled when dir is set
+	dir            string
+	maxSize        int64
+	maxInvocations int
+	startTime      int64
+	sequence       int
+	currentSize    int64
+	invocations    int
+}
+
+// NewFileReporter returns a Reporter which appends the data to the file at
+// the given path in NDJSON format. The file is created if it does not exist.
+func NewFileReporter(path string) Reporter {
+	return &fileReporter{
+		path:     path,
+		reported: new(uint32),
+	}
+}
+
+// NewRotatingFileReporter returns a Reporter which writes the data in NDJSON format to files under
+// the given directory. A new file is started when the current file would exceed maxSize bytes or it
+// has the data of maxInvocations invocations. Limits which are not positive are ignored.
+// The files can be sent to the collector later with ReplayFile.
+func NewRotatingFileReporter(dir string, maxSize int64, maxInvocations int) Reporter {
+	return &fileReporter{
+		dir:            dir,
+		maxSize:        maxSize,
+		maxInvocations: maxInvocations,
+		startTime:      time.Now().UnixNano(),
+		reported:       new(uint32),
+	}
+}
+
+// Collect collects the data from plugins
+func (r *fileReporter) Collect(messages []plugin.MonitoringDataWrapper) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messageQueue = append(r.messageQueue, messages...)
+}
+
+// Report appends the collected data to the file
+func (r *fileReporter) Report() {
+	atomic.CompareAndSwapUint32(r.reported, 0, 1)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err := r.writeMessages(); err != nil {
+		log.Println("Error while writing monitoring data to file:", err)
+	}
+}
+
+func (r *fileReporter) writeMessages() error {
+	if len(r.messageQueue) == 0 {
+		return nil
+	}
+	if r.dir != "" && (r.path == "" || (r.maxInvocations > 0 && r.invocations >= r.maxInvocations)) {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	// f is replaced on rotation
+	defer func() { f.Close() }()
+
+	w := bufio.NewWriter(f)
+	var line bytes.Buffer
+	encoder := json.NewEncoder(&line)
+	for i := range r.messageQueue {
+		line.Reset()
+		// The API key is not written to the file, it is read from the config when the file is replayed
+		message := withBaseData(r.messageQueue[i])
+		message.APIKey = ""
+		// Encode writes a newline after each value
+		if err := encoder.Encode(message); err != nil {
+			return err
+		}
+		if r.dir != "" && r.maxSize > 0 && r.currentSize > 0 && r.currentSize+int64(line.Len()) > r.maxSize {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			f.Close()
+			if err := r.rotate(); err != nil {
+				return err
+			}
+			if f, err = os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+				return err
+			}
+			w.Reset(f)
+		}
+		if _, err := w.Write(line.Bytes()); err != nil {
+			return err
+		}
+		r.currentSize += int64(line.Len())
+	}
+	r.invocations++
+	return w.Flush()
+}
+
+// rotate starts a new file under the directory. The file names are ordered by the time they are started.
+func (r *fileReporter) rotate() error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	r.sequence++
+	r.path = filepath.Join(r.dir, fmt.Sprintf("thundra-%020d-%06d.ndjson", r.startTime, r.sequence))
+	r.currentSize = 0
+	r.invocations = 0
+	return nil
+}
+
+// withBaseData adds the application fields to the data, which are omitted by the plugins when
+// composite data is enabled, so that each line can be sent to the collector on its own
+func withBaseData(message plugin.MonitoringDataWrapper) plugin.MonitoringDataWrapper {
+	data, err := toMap(message.Data)
+	if err != nil {
+		return message
+	}
+	baseData, err := toMap(plugin.PrepareBaseData())
+	if err != nil {
+		return message
+	}
+	for k, v := range baseData {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
+	message.Data = data
+	return message
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ClearData clears the reporter data
+func (r *fileReporter) ClearData() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messageQueue = r.messageQueue[:0]
+}
+
+// Reported returns reported
+func (r *fileReporter) Reported() *uint32 {
+	return r.reported
+}
+
+// FlushFlag flushes the reported flag
+func (r *fileReporter) FlushFlag() {
+	atomic.CompareAndSwapUint32(r.reported, 1, 0)
+}
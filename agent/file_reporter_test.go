@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func TestFileReporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thundra")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "monitoring.ndjson")
+
+	span := plugin.WrapMonitoringData(map[string]interface{}{"id": "span-id"}, "Span")
+	span.APIKey = "test-api-key"
+	r := NewFileReporter(path)
+	r.Collect([]plugin.MonitoringDataWrapper{
+		span,
+		plugin.WrapMonitoringData(map[string]interface{}{"id": "invocation-id"}, "Invocation"),
+	})
+	r.Report()
+	r.ClearData()
+	r.Collect([]plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(map[string]interface{}{"id": "metric-id"}, "Metric")})
+	r.Report()
+
+	b, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.NotContains(t, string(b), "test-api-key")
+
+	var types []string
+	for _, line := range lines {
+		var data plugin.MonitoringDataWrapper
+		assert.Nil(t, json.Unmarshal([]byte(line), &data))
+		types = append(types, data.Type)
+	}
+	assert.Equal(t, []string{"Span", "Invocation", "Metric"}, types)
+}
+
+func readNDJSONFiles(t *testing.T, dir string) [][]string {
+	infos, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	var files [][]string
+	for _, info := range infos {
+		b, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		assert.Nil(t, err)
+		files = append(files, strings.Split(strings.TrimSpace(string(b)), "\n"))
+	}
+	return files
+}
+
+func TestRotatingFileReporterRotatesByInvocations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thundra")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	r := NewRotatingFileReporter(filepath.Join(dir, "data"), 0, 2)
+	for i := 0; i < 5; i++ {
+		r.Collect([]plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(map[string]interface{}{"id": i}, "Invocation")})
+		r.Report()
+		r.ClearData()
+	}
+
+	files := readNDJSONFiles(t, filepath.Join(dir, "data"))
+	assert.Equal(t, 3, len(files))
+	assert.Equal(t, []int{2, 2, 1}, []int{len(files[0]), len(files[1]), len(files[2])})
+}
+
+func TestRotatingFileReporterRotatesBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thundra")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	r := NewRotatingFileReporter(dir, 1024, 0)
+	var messages []plugin.MonitoringDataWrapper
+	for i := 0; i < 10; i++ {
+		messages = append(messages, plugin.WrapMonitoringData(map[string]interface{}{"value": strings.Repeat("a", 200)}, "Span"))
+	}
+	r.Collect(messages)
+	r.Report()
+
+	files := readNDJSONFiles(t, dir)
+	assert.True(t, len(files) > 1)
+	lines := 0
+	for _, file := range files {
+		lines += len(file)
+		assert.True(t, len(strings.Join(file, "\n"))+1 <= 1024)
+	}
+	assert.Equal(t, 10, lines)
+}
+
+func TestFileReporterAddsBaseData(t *testing.T) {
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+	dir, err := ioutil.TempDir("", "thundra")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	r := NewRotatingFileReporter(dir, 0, 0)
+	r.Collect([]plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(map[string]interface{}{"id": "span-id", "applicationName": "custom"}, "Span")})
+	r.Report()
+
+	var message plugin.MonitoringDataWrapper
+	assert.Nil(t, json.Unmarshal([]byte(readNDJSONFiles(t, dir)[0][0]), &message))
+	data := message.Data.(map[string]interface{})
+	assert.Equal(t, "span-id", data["id"])
+	assert.Equal(t, "custom", data["applicationName"])
+	assert.Equal(t, test.ApplicationStage, data["applicationStage"])
+}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+// newConnCountingServer returns a server which counts the connections opened to it
+func newConnCountingServer(conns *int32, requests *int32) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(conns, 1)
+		}
+	}
+	server.Start()
+	return server
+}
+
+func TestCreateHTTPClient(t *testing.T) {
+	client, err := createHTTPClient(currentSettings())
+	assert.Nil(t, err)
+	assert.Equal(t, config.ReportRestTimeout, client.Timeout)
+
+	tr := client.Transport.(*http.Transport)
+	assert.NotNil(t, tr.Proxy)
+	assert.False(t, tr.DisableKeepAlives)
+	assert.Equal(t, config.ReportRestConnectTimeout, tr.TLSHandshakeTimeout)
+}
+
+func TestHTTPClientFollowsSettings(t *testing.T) {
+	s := currentSettings()
+	s.ReportRestTimeout = 7 * time.Second
+	client := getHTTPClient(s)
+	assert.Equal(t, 7*time.Second, client.Timeout)
+	assert.True(t, client == getHTTPClient(s))
+	assert.False(t, client == getHTTPClient(currentSettings()))
+
+	// Reporting is disabled only for the settings with the invalid TLS configuration
+	s.ReportRestCABundle = "/nonexistent/ca.pem"
+	assert.Nil(t, getHTTPClient(s))
+	assert.NotNil(t, getHTTPClient(currentSettings()))
+}
+
+func TestHTTPClientReusesConnections(t *testing.T) {
+	var conns, requests int32
+	server := newConnCountingServer(&conns, &requests)
+	defer server.Close()
+
+	client, _ := createHTTPClient(currentSettings())
+	r := &reporterImpl{client: client, reported: new(uint32)}
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, r.sendWithRetry(currentSettings(), collectorBatch{URL: server.URL, Body: []byte("[]")}))
+	}
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func TestPrewarmHTTPClient(t *testing.T) {
+	var conns, requests int32
+	server := newConnCountingServer(&conns, &requests)
+	defer server.Close()
+	client, _ := createHTTPClient(currentSettings())
+	prewarmHTTPClient(client, server.URL)
+	r := &reporterImpl{client: client, reported: new(uint32)}
+	assert.Nil(t, r.sendWithRetry(currentSettings(), collectorBatch{URL: server.URL, Body: []byte("[]")}))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func TestExecutePostHooksRecordsReportLatency(t *testing.T) {
+	defer atomic.StoreInt64(&lastReportLatency, 0)
+	defer func() { plugin.LastReportLatency = 0 }()
+
+	r := test.NewMockReporter()
+	r.ExpectedCalls = nil
+	r.On("ClearData").Return()
+	r.On("Report").Return().Run(func(_ mock.Arguments) { time.Sleep(20 * time.Millisecond) })
+	a := New().SetReporter(r)
+
+	a.ExecutePostHooks(context.TODO(), nil, nil, nil)
+	a.ExecutePreHooks(context.TODO(), createRawMessage())
+
+	assert.True(t, plugin.LastReportLatency >= 20)
+}
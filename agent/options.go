@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+)
+
+// Option configures an Agent. The settings given with options override the ones read from the
+// environment for the invocations of that agent only, so agents with different settings can be
+// used in the same process. The global settings are not changed, the settings of the agent are
+// passed to the plugins and the integrations with the context of the invocation.
+type Option func(*Agent)
+
+// WithSettings changes the settings of the agent. The changes are applied to the current settings
+// in every invocation, so the other settings still follow the remote config,
+// e.g. WithSettings(func(s *config.Settings) { s.MaskHTTPBody = true })
+func WithSettings(configure func(s *config.Settings)) Option {
+	return func(a *Agent) {
+		a.overrides = append(a.overrides, configure)
+	}
+}
+
+// WithCollectorURL sets the base URL of the Thundra collector such as https://collector.thundra.io/v1
+func WithCollectorURL(url string) Option {
+	return WithSettings(func(s *config.Settings) {
+		s.CollectorUrl = url
+	})
+}
+
+// WithRestCompositeBatchSize sets the maximum number of monitoring data sent to the collector in a request
+func WithRestCompositeBatchSize(size int) Option {
+	return WithSettings(func(s *config.Settings) {
+		s.ReportRestCompositeBatchSize = size
+	})
+}
+
+// WithCloudwatchCompositeBatchSize sets the maximum number of monitoring data written to CloudWatch in a line
+func WithCloudwatchCompositeBatchSize(size int) Option {
+	return WithSettings(func(s *config.Settings) {
+		s.ReportCloudwatchCompositeBatchSize = size
+	})
+}
+
+// WithTimeoutMargin sets how long before the deadline of the invocation the data is reported as timed out
+func WithTimeoutMargin(margin time.Duration) Option {
+	return WithSettings(func(s *config.Settings) {
+		s.TimeoutMargin = margin
+	})
+}
+
+// WithTraceSampler sets the sampler of the spans of the agent
+func WithTraceSampler(sampler samplers.Sampler) Option {
+	return withSampler("Span", sampler)
+}
+
+// WithMetricSampler sets the sampler of the metrics of the agent
+func WithMetricSampler(sampler samplers.Sampler) Option {
+	return withSampler("Metric", sampler)
+}
+
+// WithLogSampler sets the sampler of the logs of the agent
+func WithLogSampler(sampler samplers.Sampler) Option {
+	return withSampler("Log", sampler)
+}
+
+func withSampler(dataType string, sampler samplers.Sampler) Option {
+	return func(a *Agent) {
+		if a.samplers == nil {
+			a.samplers = map[string]samplers.Sampler{}
+		}
+		a.samplers[dataType] = sampler
+	}
+}
+
+// WithSpanListeners sets the span listeners of the agent instead of the ones given in the environment
+func WithSpanListeners(listeners ...tracer.ThundraSpanListener) Option {
+	return func(a *Agent) {
+		a.spanListeners = append([]tracer.ThundraSpanListener{}, listeners...)
+	}
+}
+
+// invocationSettings returns a copy of the current settings with the changes given by the options of the agent
+func (a *Agent) invocationSettings() *config.Settings {
+	s := currentSettings()
+	for _, configure := range a.overrides {
+		configure(s)
+	}
+	return s
+}
+
+type agentKey struct{}
+
+// invocationContext returns a copy of ctx carrying the settings, the samplers and the span listeners
+// of the agent for an invocation
+func (a *Agent) invocationContext(ctx context.Context) context.Context {
+	if ctx.Value(agentKey{}) == a {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, agentKey{}, a)
+	ctx = config.ContextWithSettings(ctx, a.invocationSettings())
+	for dataType, sampler := range a.samplers {
+		ctx = samplers.ContextWithSampler(ctx, dataType, sampler)
+	}
+	if a.spanListeners != nil {
+		ctx = tracer.ContextWithSpanListeners(ctx, a.spanListeners)
+	}
+	return ctx
+}
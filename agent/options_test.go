@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+)
+
+// settingsRecordingPlugin records the settings passed with the context of the invocation
+type settingsRecordingPlugin struct {
+	maskHTTPBody  bool
+	batchSize     int
+	spanListeners int
+	sampler       samplers.Sampler
+}
+
+func (p *settingsRecordingPlugin) IsEnabled() bool {
+	return true
+}
+
+func (p *settingsRecordingPlugin) Order() uint8 {
+	return 4
+}
+
+func (p *settingsRecordingPlugin) BeforeExecution(ctx context.Context, request json.RawMessage) context.Context {
+	s := config.SettingsFromContext(ctx)
+	p.maskHTTPBody = s.MaskHTTPBody
+	p.batchSize = s.ReportRestCompositeBatchSize
+	p.spanListeners = len(tracer.SpanListenersFromContext(ctx))
+	return ctx
+}
+
+func (p *settingsRecordingPlugin) AfterExecution(ctx context.Context, request json.RawMessage, response interface{}, err interface{}) ([]plugin.MonitoringDataWrapper, context.Context) {
+	p.sampler = samplers.FromContext(ctx, "Span", nil)
+	return []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")}, ctx
+}
+
+func TestNewWithOptions(t *testing.T) {
+	margin := config.TimeoutMargin
+	a := New(WithTimeoutMargin(42*time.Millisecond), WithRestCompositeBatchSize(7))
+
+	assert.Equal(t, 42*time.Millisecond, a.TimeoutMargin)
+	assert.Equal(t, 7, a.invocationSettings().ReportRestCompositeBatchSize)
+	// The global settings are not changed
+	assert.Equal(t, margin, config.TimeoutMargin)
+	assert.NotEqual(t, 7, config.ReportRestCompositeBatchSize)
+}
+
+func TestNewWithoutOptionsFollowsGlobalSettings(t *testing.T) {
+	a := New()
+
+	assert.Empty(t, a.overrides)
+	assert.Equal(t, config.TimeoutMargin, a.TimeoutMargin)
+}
+
+func TestOptionsKeepFollowingReloadedSettings(t *testing.T) {
+	a := New(WithRestCompositeBatchSize(7))
+
+	// e.g. changed by the remote config after the agent is created
+	maskHTTPBody := config.MaskHTTPBody
+	config.MaskHTTPBody = !maskHTTPBody
+	defer func() { config.MaskHTTPBody = maskHTTPBody }()
+
+	s := a.invocationSettings()
+	assert.Equal(t, !maskHTTPBody, s.MaskHTTPBody)
+	assert.Equal(t, 7, s.ReportRestCompositeBatchSize)
+}
+
+func TestOptionsAreAppliedDuringInvocation(t *testing.T) {
+	listener := tracer.NewTagInjectorSpanListener(map[string]interface{}{})
+	p := &settingsRecordingPlugin{}
+	a := New(
+		WithSettings(func(s *config.Settings) { s.MaskHTTPBody = true }),
+		WithRestCompositeBatchSize(7),
+		WithSpanListeners(listener),
+	).SetReporter(test.NewMockReporter()).AddPlugin(p)
+
+	ctx := a.ExecutePreHooks(context.TODO(), createRawMessage())
+	assert.True(t, p.maskHTTPBody)
+	assert.Equal(t, 7, p.batchSize)
+	assert.Equal(t, 1, p.spanListeners)
+	// The global settings are not changed during the invocation
+	assert.False(t, config.MaskHTTPBody)
+	assert.NotEqual(t, 7, config.ReportRestCompositeBatchSize)
+	assert.Empty(t, tracer.GetSpanListeners())
+
+	a.ExecutePostHooks(ctx, createRawMessage(), nil, nil)
+}
+
+func TestAgentsWithDifferentSettings(t *testing.T) {
+	masking, notMasking := &settingsRecordingPlugin{}, &settingsRecordingPlugin{}
+	a1 := New(WithSettings(func(s *config.Settings) { s.MaskHTTPBody = true })).
+		SetReporter(test.NewMockReporter()).AddPlugin(masking)
+	a2 := New().SetReporter(test.NewMockReporter()).AddPlugin(notMasking)
+
+	ctx1 := a1.ExecutePreHooks(context.TODO(), createRawMessage())
+	ctx2 := a2.ExecutePreHooks(context.TODO(), createRawMessage())
+	assert.True(t, masking.maskHTTPBody)
+	assert.False(t, notMasking.maskHTTPBody)
+
+	a1.ExecutePostHooks(ctx1, createRawMessage(), nil, nil)
+	a2.ExecutePostHooks(ctx2, createRawMessage(), nil, nil)
+}
+
+func TestWithCollectorURL(t *testing.T) {
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+	url := config.CollectorUrl
+
+	var requestURL string
+	r := &httpReporter{newTestReporter(func(req *http.Request) (*http.Response, error) {
+		requestURL = req.URL.String()
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})}
+	a := New(WithCollectorURL("https://collector.example.com/v1")).SetReporter(r).AddPlugin(&settingsRecordingPlugin{})
+
+	ctx := a.ExecutePreHooks(context.TODO(), createRawMessage())
+	a.ExecutePostHooks(ctx, createRawMessage(), nil, nil)
+
+	assert.True(t, strings.HasPrefix(requestURL, "https://collector.example.com/v1/"))
+	assert.Equal(t, url, config.CollectorUrl)
+}
+
+func TestWithTraceSampler(t *testing.T) {
+	sampler := samplers.NewCountAwareSampler()
+	p := &settingsRecordingPlugin{}
+	a := New(WithTraceSampler(sampler)).SetReporter(test.NewMockReporter()).AddPlugin(p)
+
+	handler := a.Wrap(func() error { return nil }).(func(context.Context, json.RawMessage) (interface{}, error))
+	handler(context.TODO(), createRawMessage())
+
+	assert.Equal(t, sampler, p.sampler)
+}
@@ -0,0 +1,543 @@
+package agent
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+const otlpScopeName = "thundra-lambda-agent-go"
+
+// Span kinds as defined by the OTLP protocol
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+	otlpSpanKindProducer = 4
+)
+
+const otlpStatusCodeError = 2
+
+// otlpAttributeNames maps Thundra tags to their OpenTelemetry semantic convention names.
+// Tags which are not listed here are exported with their own names.
+var otlpAttributeNames = map[string]string{
+	constants.HTTPTags["METHOD"]:                "http.request.method",
+	constants.HTTPTags["URL"]:                   "url.full",
+	constants.HTTPTags["PATH"]:                  "url.path",
+	constants.HTTPTags["HOST"]:                  "server.address",
+	constants.HTTPTags["STATUS"]:                "http.response.status_code",
+	constants.HTTPTags["QUERY_PARAMS"]:          "url.query",
+	constants.DBTags["DB_TYPE"]:                 "db.system",
+	constants.DBTags["DB_INSTANCE"]:             "db.name",
+	constants.DBTags["DB_HOST"]:                 "server.address",
+	constants.DBTags["DB_PORT"]:                 "server.port",
+	constants.DBTags["DB_STATEMENT_TYPE"]:       "db.operation",
+	constants.RedisTags["REDIS_HOST"]:           "server.address",
+	constants.RedisTags["REDIS_PORT"]:           "server.port",
+	constants.RedisTags["REDIS_COMMAND"]:        "db.operation",
+	constants.MongoDBTags["MONGODB_COMMAND"]:    "db.statement",
+	constants.MongoDBTags["MONGODB_COLLECTION"]: "db.mongodb.collection",
+	constants.AwsDynamoDBTags["TABLE_NAME"]:     "aws.dynamodb.table_names",
+	constants.AwsSQSTags["QUEUE_NAME"]:          "messaging.destination.name",
+	constants.AwsSNSTags["TOPIC_NAME"]:          "messaging.destination.name",
+	constants.AwsS3Tags["BUCKET_NAME"]:          "aws.s3.bucket",
+	constants.AwsS3Tags["OBJECT_NAME"]:          "aws.s3.key",
+	constants.AwsSDKTags["SERVICE_NAME"]:        "rpc.service",
+	constants.AwsSDKTags["REQUEST_NAME"]:        "rpc.method",
+	constants.AwsLambdaInvocationRequestId:      "faas.invocation_id",
+	constants.AwsLambdaInvocationColdStart:      "faas.coldstart",
+	constants.AwsLambdaARN:                      "cloud.resource_id",
+	constants.AwsAccountNo:                      "cloud.account.id",
+	constants.AwsRegion:                         "cloud.region",
+	constants.AwsLambdaName:                     "faas.name",
+	constants.AwsErrorKind:                      "exception.type",
+	constants.AwsErrorMessage:                   "exception.message",
+	constants.AwsErrorStack:                     "exception.stacktrace",
+}
+
+// otlpSystems maps Thundra class names to the OpenTelemetry system attribute
+// describing the called service
+var otlpSystems = map[string][2]string{
+	constants.ClassNames["DYNAMODB"]:      {"db.system", "dynamodb"},
+	constants.ClassNames["REDIS"]:         {"db.system", "redis"},
+	constants.ClassNames["MYSQL"]:         {"db.system", "mysql"},
+	constants.ClassNames["POSTGRESQL"]:    {"db.system", "postgresql"},
+	constants.ClassNames["MONGODB"]:       {"db.system", "mongodb"},
+	constants.ClassNames["ELASTICSEARCH"]: {"db.system", "elasticsearch"},
+	constants.ClassNames["SQS"]:           {"messaging.system", "aws_sqs"},
+	constants.ClassNames["SNS"]:           {"messaging.system", "aws_sns"},
+	constants.ClassNames["KINESIS"]:       {"rpc.system", "aws-api"},
+	constants.ClassNames["FIREHOSE"]:      {"rpc.system", "aws-api"},
+	constants.ClassNames["S3"]:            {"rpc.system", "aws-api"},
+	constants.ClassNames["LAMBDA"]:        {"rpc.system", "aws-api"},
+	constants.ClassNames["ATHENA"]:        {"rpc.system", "aws-api"},
+	constants.ClassNames["SES"]:           {"rpc.system", "aws-api"},
+	constants.ClassNames["AWSSERVICE"]:    {"rpc.system", "aws-api"},
+}
+
+// otlpTriggers maps trigger class names to the faas.trigger values
+var otlpTriggers = map[string]string{
+	constants.ClassNames["APIGATEWAY"]: "http",
+	constants.ClassNames["HTTP"]:       "http",
+	constants.ClassNames["SQS"]:        "pubsub",
+	constants.ClassNames["SNS"]:        "pubsub",
+	constants.ClassNames["KINESIS"]:    "pubsub",
+	constants.ClassNames["FIREHOSE"]:   "pubsub",
+	constants.ClassNames["DYNAMODB"]:   "datasource",
+	constants.ClassNames["S3"]:         "datasource",
+	constants.ClassNames["SCHEDULE"]:   "timer",
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes   []otlpKeyValue `json:"attributes"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	AsInt        *string        `json:"asInt,omitempty"`
+	AsDouble     *float64       `json:"asDouble,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name  string    `json:"name"`
+	Gauge otlpGauge `json:"gauge"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+// sendOTLP converts the collected spans, invocations and metrics to OTLP/HTTP JSON
+// and posts them to the configured OTLP endpoint. Other data types are not exported.
+func (r *reporterImpl) sendOTLP(s *config.Settings) {
+	spans, metrics := toOTLP(r.messageQueue)
+	resource := otlpResource{Attributes: otlpResourceAttributes()}
+	scope := otlpScope{Name: otlpScopeName, Version: constants.AgentVersion}
+
+	if s.DebugEnabled {
+		log.Println("Sending OTLP requests to: " + s.ReportOTLPEndpoint)
+	}
+
+	var wg sync.WaitGroup
+	spanData := make([]interface{}, len(spans))
+	for i := range spans {
+		spanData[i] = spans[i]
+	}
+	for _, batch := range batchConverted(s, spanDataType, spanData, jsonSize(newOTLPTracesRequest(resource, scope, nil))) {
+		batchSpans := make([]otlpSpan, 0, len(batch))
+		for _, data := range batch {
+			if span, ok := data.(otlpSpan); ok {
+				batchSpans = append(batchSpans, span)
+			}
+		}
+		b, err := json.Marshal(newOTLPTracesRequest(resource, scope, batchSpans))
+		if err != nil {
+			recordSerializationError()
+			log.Println("Error in marshalling ", err)
+			continue
+		}
+		wg.Add(1)
+		go r.sendOTLPBatch(s, s.ReportOTLPEndpoint+constants.OTLPTracesPath, b, &wg)
+	}
+	metricData := make([]interface{}, len(metrics))
+	for i := range metrics {
+		metricData[i] = metrics[i]
+	}
+	for _, batch := range batchConverted(s, metricDataType, metricData, jsonSize(newOTLPMetricsRequest(resource, scope, nil))) {
+		batchMetrics := make([]otlpMetric, 0, len(batch))
+		for _, data := range batch {
+			if metric, ok := data.(otlpMetric); ok {
+				batchMetrics = append(batchMetrics, metric)
+			}
+		}
+		b, err := json.Marshal(newOTLPMetricsRequest(resource, scope, batchMetrics))
+		if err != nil {
+			recordSerializationError()
+			log.Println("Error in marshalling ", err)
+			continue
+		}
+		wg.Add(1)
+		go r.sendOTLPBatch(s, s.ReportOTLPEndpoint+constants.OTLPMetricsPath, b, &wg)
+	}
+	wg.Wait()
+}
+
+func newOTLPTracesRequest(resource otlpResource, scope otlpScope, spans []otlpSpan) otlpTracesRequest {
+	return otlpTracesRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   resource,
+			ScopeSpans: []otlpScopeSpans{{Scope: scope, Spans: spans}},
+		}},
+	}
+}
+
+func newOTLPMetricsRequest(resource otlpResource, scope otlpScope, metrics []otlpMetric) otlpMetricsRequest {
+	return otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource:     resource,
+			ScopeMetrics: []otlpScopeMetrics{{Scope: scope, Metrics: metrics}},
+		}},
+	}
+}
+
+func (r *reporterImpl) sendOTLPBatch(s *config.Settings, targetURL string, messages []byte, wg *sync.WaitGroup) {
+	batch := newCollectorBatch(s, targetURL, jsonContentType, messages)
+	batch.Protocol = otlpProtocol
+	r.sendBatch(s, batch, wg)
+}
+
+// toOTLP converts the monitoring data to OTLP spans and metrics. Invocations are
+// merged into their root spans, or exported as server spans if trace is disabled.
+func toOTLP(messages []plugin.MonitoringDataWrapper) ([]otlpSpan, []otlpMetric) {
+	var spans []otlpSpan
+	var metrics []otlpMetric
+	var invocations []monitoringData
+	spanIndexes := map[string]int{}
+
+	for i := range messages {
+		src, err := decodeMonitoringData(messages[i].Data)
+		if err != nil {
+			log.Println("Error in converting monitoring data to OTLP:", err)
+			continue
+		}
+		switch messages[i].Type {
+		case spanDataType:
+			spanIndexes[src.ID] = len(spans)
+			spans = append(spans, spanToOTLP(src))
+		case invocationDataType:
+			invocations = append(invocations, src)
+		case metricDataType:
+			metrics = append(metrics, metricToOTLP(src)...)
+		}
+	}
+
+	for _, inv := range invocations {
+		if i, ok := spanIndexes[inv.SpanID]; ok {
+			mergeInvocationToOTLPSpan(&spans[i], inv)
+			continue
+		}
+		spanID := inv.SpanID
+		if spanID == "" {
+			spanID = inv.ID
+		}
+		span := otlpSpan{
+			TraceID:           utils.ToW3CTraceID(inv.TraceID),
+			SpanID:            utils.ToW3CSpanID(spanID),
+			Name:              application.ApplicationName,
+			StartTimeUnixNano: msToUnixNano(inv.StartTimestamp),
+			EndTimeUnixNano:   msToUnixNano(inv.FinishTimestamp),
+		}
+		mergeInvocationToOTLPSpan(&span, inv)
+		spans = append(spans, span)
+	}
+	return spans, metrics
+}
+
+func spanToOTLP(src monitoringData) otlpSpan {
+	kind := otlpSpanKind(src)
+	span := otlpSpan{
+		TraceID:           utils.ToW3CTraceID(src.TraceID),
+		SpanID:            utils.ToW3CSpanID(src.ID),
+		Name:              src.OperationName,
+		Kind:              kind,
+		StartTimeUnixNano: msToUnixNano(src.StartTimestamp),
+		EndTimeUnixNano:   msToUnixNano(src.FinishTimestamp),
+	}
+	if src.ParentSpanID != "" {
+		span.ParentSpanID = utils.ToW3CSpanID(src.ParentSpanID)
+	}
+
+	attributes := map[string]interface{}{
+		"thundra.domain_name":    src.DomainName,
+		"thundra.class_name":     src.ClassName,
+		"thundra.transaction_id": src.TransactionID,
+	}
+	if system, ok := otlpSystems[src.ClassName]; ok {
+		attributes[system[0]] = system[1]
+	}
+	for k, v := range src.Tags {
+		if k == constants.AwsLambdaName && kind == otlpSpanKindClient {
+			attributes["faas.invoked_name"] = v
+		} else {
+			attributes[otlpAttributeName(k)] = v
+		}
+	}
+	span.Attributes = toOTLPAttributes(attributes)
+
+	if erroneous, _ := src.Tags[constants.AwsError].(bool); erroneous {
+		message, _ := src.Tags[constants.AwsErrorMessage].(string)
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: message}
+	}
+	return span
+}
+
+func mergeInvocationToOTLPSpan(span *otlpSpan, inv monitoringData) {
+	span.Kind = otlpSpanKindServer
+
+	attributes := map[string]interface{}{
+		"faas.coldstart":         inv.ColdStart,
+		"thundra.transaction_id": inv.TransactionID,
+	}
+	if inv.Timeout {
+		attributes[constants.AwsLambdaInvocationTimeout] = true
+	}
+	if trigger, ok := inv.Tags[constants.SpanTags["TRIGGER_CLASS_NAME"]].(string); ok {
+		if faasTrigger, ok := otlpTriggers[trigger]; ok {
+			attributes["faas.trigger"] = faasTrigger
+		} else {
+			attributes["faas.trigger"] = "other"
+		}
+	}
+	for k, v := range inv.Tags {
+		attributes[otlpAttributeName(k)] = v
+	}
+	for k, v := range inv.UserTags {
+		attributes[k] = v
+	}
+
+	// Invocation attributes take precedence over the ones of the root span
+	for _, kv := range span.Attributes {
+		if _, ok := attributes[kv.Key]; !ok {
+			attributes[kv.Key] = kv.Value
+		}
+	}
+	span.Attributes = toOTLPAttributes(attributes)
+
+	if inv.Erroneous {
+		span.Status = otlpStatus{Code: otlpStatusCodeError, Message: inv.ErrorMessage}
+	}
+}
+
+func metricToOTLP(src monitoringData) []otlpMetric {
+	attributes := map[string]interface{}{
+		"thundra.metric_name":    src.MetricName,
+		"thundra.trace_id":       src.TraceID,
+		"thundra.transaction_id": src.TransactionID,
+	}
+	for k, v := range src.Tags {
+		attributes[otlpAttributeName(k)] = v
+	}
+	dataPointAttributes := toOTLPAttributes(attributes)
+
+	names := make([]string, 0, len(src.Metrics))
+	for name := range src.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var metrics []otlpMetric
+	for _, name := range names {
+		dataPoint := otlpNumberDataPoint{
+			Attributes:   dataPointAttributes,
+			TimeUnixNano: msToUnixNano(src.MetricTimestamp),
+		}
+		switch v := src.Metrics[name].(type) {
+		case json.Number:
+			if _, err := v.Int64(); err == nil {
+				asInt := v.String()
+				dataPoint.AsInt = &asInt
+			} else if f, err := v.Float64(); err == nil {
+				dataPoint.AsDouble = &f
+			} else {
+				continue
+			}
+		default:
+			// Only numeric metrics can be exported as gauges
+			continue
+		}
+		metrics = append(metrics, otlpMetric{
+			Name:  name,
+			Gauge: otlpGauge{DataPoints: []otlpNumberDataPoint{dataPoint}},
+		})
+	}
+	return metrics
+}
+
+func otlpSpanKind(src monitoringData) int {
+	if src.ParentSpanID == "" {
+		return otlpSpanKindServer
+	}
+	switch src.DomainName {
+	case constants.DomainNames["MESSAGING"]:
+		return otlpSpanKindProducer
+	case constants.DomainNames["API"], constants.DomainNames["DB"], constants.DomainNames["CACHE"],
+		constants.DomainNames["STORAGE"], constants.DomainNames["STREAM"], constants.DomainNames["AWS"],
+		constants.DomainNames["CDN"]:
+		return otlpSpanKindClient
+	}
+	return otlpSpanKindInternal
+}
+
+func otlpAttributeName(tag string) string {
+	if name, ok := otlpAttributeNames[tag]; ok {
+		return name
+	}
+	return tag
+}
+
+func otlpResourceAttributes() []otlpKeyValue {
+	attributes := map[string]interface{}{}
+	for k, v := range application.ApplicationTags {
+		attributes[k] = v
+	}
+	for k, v := range map[string]string{
+		"service.name":           application.ApplicationName,
+		"service.version":        application.ApplicationVersion,
+		"service.instance.id":    application.ApplicationInstanceID,
+		"deployment.environment": application.ApplicationStage,
+		"cloud.provider":         "aws",
+		"cloud.platform":         "aws_lambda",
+		"cloud.region":           application.FunctionRegion,
+		"faas.name":              application.FunctionName,
+		"faas.version":           application.ApplicationVersion,
+		"telemetry.sdk.name":     "thundra",
+		"telemetry.sdk.language": "go",
+		"telemetry.sdk.version":  constants.AgentVersion,
+	} {
+		if v != "" {
+			attributes[k] = v
+		}
+	}
+	if application.MemoryLimit > 0 {
+		attributes["faas.max_memory"] = application.MemoryLimit
+	}
+	return toOTLPAttributes(attributes)
+}
+
+// toOTLPAttributes converts the given attributes to OTLP key values sorted by key
+func toOTLPAttributes(attributes map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		if attributes[k] == nil {
+			continue
+		}
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: toOTLPAnyValue(attributes[k])})
+	}
+	return kvs
+}
+
+func toOTLPAnyValue(value interface{}) otlpAnyValue {
+	switch v := value.(type) {
+	case otlpAnyValue:
+		return v
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			s := v.String()
+			return otlpAnyValue{IntValue: &s}
+		}
+		f, _ := v.Float64()
+		return otlpAnyValue{DoubleValue: &f}
+	case []interface{}:
+		values := make([]otlpAnyValue, 0, len(v))
+		for _, item := range v {
+			values = append(values, toOTLPAnyValue(item))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	}
+	// Nested values are exported as JSON strings
+	b, err := json.Marshal(value)
+	if err != nil {
+		s := ""
+		return otlpAnyValue{StringValue: &s}
+	}
+	s := string(b)
+	return otlpAnyValue{StringValue: &s}
+}
+
+func msToUnixNano(ms int64) string {
+	return strconv.FormatInt(ms*1000000, 10)
+}
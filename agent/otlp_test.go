@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+const (
+	otlpTestTraceID = "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"
+	otlpTestRootID  = "root-span-id"
+)
+
+func otlpTestMessages() []plugin.MonitoringDataWrapper {
+	rootSpan := map[string]interface{}{
+		"id":              otlpTestRootID,
+		"traceId":         otlpTestTraceID,
+		"transactionId":   "transaction-id",
+		"domainName":      "API",
+		"className":       "AWS-Lambda",
+		"operationName":   "test-function",
+		"startTimestamp":  1000,
+		"finishTimestamp": 1500,
+		"tags":            map[string]interface{}{},
+	}
+	httpSpan := map[string]interface{}{
+		"id":              "http-span-id",
+		"traceId":         otlpTestTraceID,
+		"parentSpanId":    otlpTestRootID,
+		"domainName":      "API",
+		"className":       "HTTP",
+		"operationName":   "example.com/users",
+		"startTimestamp":  1100,
+		"finishTimestamp": 1200,
+		"tags": map[string]interface{}{
+			"http.method":      "GET",
+			"http.status_code": 500,
+			"error":            true,
+			"error.message":    "Internal Server Error",
+		},
+	}
+	invocation := map[string]interface{}{
+		"id":              "invocation-id",
+		"traceId":         otlpTestTraceID,
+		"spanId":          otlpTestRootID,
+		"startTimestamp":  1000,
+		"finishTimestamp": 1500,
+		"coldStart":       true,
+		"tags": map[string]interface{}{
+			"aws.lambda.invocation.request_id": "request-id",
+			"trigger.className":                "AWS-SQS",
+		},
+	}
+	metric := map[string]interface{}{
+		"traceId":         otlpTestTraceID,
+		"metricName":      "HeapMetric",
+		"metricTimestamp": 1500,
+		"metrics": map[string]interface{}{
+			"app.heapAlloc":    1024,
+			"app.heapUsedPerc": 12.5,
+		},
+		"tags": map[string]interface{}{"aws.region": "us-west-2"},
+	}
+	return []plugin.MonitoringDataWrapper{
+		plugin.WrapMonitoringData(rootSpan, "Span"),
+		plugin.WrapMonitoringData(httpSpan, "Span"),
+		plugin.WrapMonitoringData(invocation, "Invocation"),
+		plugin.WrapMonitoringData(metric, "Metric"),
+	}
+}
+
+func otlpAttribute(attributes []otlpKeyValue, key string) *otlpAnyValue {
+	for i := range attributes {
+		if attributes[i].Key == key {
+			return &attributes[i].Value
+		}
+	}
+	return nil
+}
+
+func TestReportOTLP(t *testing.T) {
+	config.ReportOTLPEnabled = true
+	config.ReportOTLPEndpoint = "http://localhost:4318"
+	config.ReportOTLPHeaders = map[string]string{"X-Collector-Key": "secret"}
+	defer func() { config.ReportOTLPEnabled = false }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var lock sync.Mutex
+	bodies := map[string][]byte{}
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "secret", req.Header.Get("X-Collector-Key"))
+		assert.Empty(t, req.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(req.Body)
+		lock.Lock()
+		bodies[req.URL.Path] = body
+		lock.Unlock()
+		return &(http.Response{}), nil
+	})
+	testReporter.messageQueue = otlpTestMessages()
+	testReporter.Report()
+
+	var traces otlpTracesRequest
+	assert.Nil(t, json.Unmarshal(bodies["/v1/traces"], &traces))
+	assert.Equal(t, test.ApplicationName, *otlpAttribute(traces.ResourceSpans[0].Resource.Attributes, "service.name").StringValue)
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, 2, len(spans))
+
+	root := spans[0]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.TraceID)
+	assert.Equal(t, utils.ToW3CSpanID(otlpTestRootID), root.SpanID)
+	assert.Equal(t, otlpSpanKindServer, root.Kind)
+	assert.Equal(t, "1000000000", root.StartTimeUnixNano)
+	assert.True(t, *otlpAttribute(root.Attributes, "faas.coldstart").BoolValue)
+	assert.Equal(t, "request-id", *otlpAttribute(root.Attributes, "faas.invocation_id").StringValue)
+	assert.Equal(t, "pubsub", *otlpAttribute(root.Attributes, "faas.trigger").StringValue)
+
+	child := spans[1]
+	assert.Equal(t, root.SpanID, child.ParentSpanID)
+	assert.Equal(t, otlpSpanKindClient, child.Kind)
+	assert.Equal(t, "GET", *otlpAttribute(child.Attributes, "http.request.method").StringValue)
+	assert.Equal(t, "500", *otlpAttribute(child.Attributes, "http.response.status_code").IntValue)
+	assert.Nil(t, otlpAttribute(child.Attributes, "http.method"))
+	assert.Equal(t, otlpStatusCodeError, child.Status.Code)
+	assert.Equal(t, "Internal Server Error", child.Status.Message)
+
+	var metrics otlpMetricsRequest
+	assert.Nil(t, json.Unmarshal(bodies["/v1/metrics"], &metrics))
+	ms := metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	assert.Equal(t, 2, len(ms))
+	assert.Equal(t, "app.heapAlloc", ms[0].Name)
+	assert.Equal(t, "1024", *ms[0].Gauge.DataPoints[0].AsInt)
+	assert.Equal(t, 12.5, *ms[1].Gauge.DataPoints[0].AsDouble)
+	assert.Equal(t, "us-west-2", *otlpAttribute(ms[1].Gauge.DataPoints[0].Attributes, "cloud.region").StringValue)
+}
+
+func TestReportOTLPBatchSize(t *testing.T) {
+	config.ReportOTLPEnabled = true
+	config.ReportRestCompositeBatchSize = 1
+	defer func() {
+		config.ReportOTLPEnabled = false
+		config.ReportRestCompositeBatchSize = 100
+	}()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var lock sync.Mutex
+	requestCount := map[string]int{}
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		lock.Lock()
+		requestCount[req.URL.Path]++
+		lock.Unlock()
+		return &(http.Response{}), nil
+	})
+	testReporter.messageQueue = otlpTestMessages()
+	testReporter.Report()
+
+	assert.Equal(t, 2, requestCount["/v1/traces"])
+	assert.Equal(t, 2, requestCount["/v1/metrics"])
+}
+
+func TestReportOTLPBatchLimits(t *testing.T) {
+	config.ReportOTLPEnabled = true
+	maxBytes := config.ReportRestMaxBytes
+	defer func() {
+		config.ReportOTLPEnabled = false
+		config.ReportRestCompositeBatchSize = 100
+		config.ReportRestMaxBytes = maxBytes
+	}()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var lock sync.Mutex
+	requestCount := map[string]int{}
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		lock.Lock()
+		requestCount[req.URL.Path]++
+		lock.Unlock()
+		return &(http.Response{}), nil
+	})
+
+	// The batch size is not limited if it is not positive
+	for _, batchSize := range []int{0, -1} {
+		requestCount = map[string]int{}
+		config.ReportRestCompositeBatchSize = batchSize
+		testReporter.messageQueue = otlpTestMessages()
+		testReporter.Report()
+		testReporter.ClearData()
+
+		assert.Equal(t, 1, requestCount["/v1/traces"], batchSize)
+		assert.Equal(t, 1, requestCount["/v1/metrics"], batchSize)
+	}
+
+	// Each span is sent in its own request if two of them do not fit
+	requestCount = map[string]int{}
+	config.ReportRestCompositeBatchSize = 100
+	spans, _ := toOTLP(otlpTestMessages())
+	resource := otlpResource{Attributes: otlpResourceAttributes()}
+	config.ReportRestMaxBytes = jsonSize(newOTLPTracesRequest(resource, otlpScope{}, spans[:1])) + 100
+	testReporter.messageQueue = otlpTestMessages()
+	testReporter.Report()
+
+	assert.Equal(t, 2, requestCount["/v1/traces"])
+}
+
+func TestOTLPInvocationWithoutSpan(t *testing.T) {
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	messages := otlpTestMessages()[2:3]
+	spans, metrics := toOTLP(messages)
+	assert.Equal(t, 0, len(metrics))
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, test.ApplicationName, spans[0].Name)
+	assert.Equal(t, otlpSpanKindServer, spans[0].Kind)
+	assert.Equal(t, utils.ToW3CSpanID(otlpTestRootID), spans[0].SpanID)
+}
+
+func TestReportOTLPRetriesAndSpools(t *testing.T) {
+	defer prepareSpool(t)()
+	config.ReportOTLPEnabled = true
+	config.ReportOTLPEndpoint = "http://localhost:4318"
+	config.ReportOTLPHeaders = map[string]string{"X-Collector-Key": "secret"}
+	config.ReportRestRetryCount = 1
+	defer func() { config.ReportOTLPEnabled = false }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var requests int32
+	failing := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, nil
+	})
+	failing.messageQueue = otlpTestMessages()
+	failing.Report()
+
+	assert.Equal(t, int32(4), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 2, len(files))
+
+	var paths []string
+	succeeding := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "secret", req.Header.Get("X-Collector-Key"))
+		assert.Empty(t, req.Header.Get("Authorization"))
+		paths = append(paths, req.URL.Path)
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	succeeding.replaySpool(currentSettings())
+
+	assert.ElementsMatch(t, []string{"/v1/traces", "/v1/metrics"}, paths)
+	files, _, _ = spoolFiles(currentSettings())
+	assert.Equal(t, 0, len(files))
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// ReplayFile sends the monitoring data in the NDJSON file written by the file reporter to the collector.
+// The data is sent with the configured API key and collector URL. It returns the first error, if any.
+func ReplayFile(path string) error {
+	messages, err := readMonitoringDataFile(path)
+	if err != nil {
+		return err
+	}
+	r := newReporter()
+	return r.replayMessages(currentSettings(), messages)
+}
+
+func readMonitoringDataFile(path string) ([]plugin.MonitoringDataWrapper, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []plugin.MonitoringDataWrapper
+	reader := bufio.NewReader(f)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			var message plugin.MonitoringDataWrapper
+			if err := decoder.Decode(&message); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, lineNumber, err)
+			}
+			if message.APIKey == "" {
+				message.APIKey = config.APIKey
+			}
+			messages = append(messages, message)
+		}
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// replayMessages sends the messages as non-composite batches since each message has its own application fields
+func (r *reporterImpl) replayMessages(s *config.Settings, messages []plugin.MonitoringDataWrapper) error {
+	targetURL := s.CollectorUrl + constants.MonitoringDataPath
+	batches := batchMessages(messages, s.ReportRestCompositeBatchSize, s.ReportRestMaxBytes, len("[]"), false)
+	for _, batch := range batches {
+		b, err := json.Marshal(batch)
+		if err != nil {
+			return err
+		}
+		if err := r.sendWithRetry(s, newCollectorBatch(s, targetURL, jsonContentType, b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
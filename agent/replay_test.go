@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+func TestReplayFile(t *testing.T) {
+	var received []plugin.MonitoringDataWrapper
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, constants.MonitoringDataPath, r.URL.Path)
+		assert.Equal(t, "ApiKey test-api-key", r.Header.Get("Authorization"))
+		var batch []plugin.MonitoringDataWrapper
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&batch))
+		received = append(received, batch...)
+	}))
+	defer server.Close()
+	url, apiKey := config.CollectorUrl, config.APIKey
+	config.CollectorUrl, config.APIKey = server.URL, "test-api-key"
+	defer func() { config.CollectorUrl, config.APIKey = url, apiKey }()
+
+	dir, err := ioutil.TempDir("", "thundra")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	r := NewRotatingFileReporter(dir, 0, 0)
+	r.Collect([]plugin.MonitoringDataWrapper{
+		plugin.WrapMonitoringData(map[string]interface{}{"id": "span-id", "duration": 12345678901}, "Span"),
+		plugin.WrapMonitoringData(map[string]interface{}{"id": "invocation-id"}, "Invocation"),
+	})
+	r.Report()
+	files, _ := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+	assert.Equal(t, 1, len(files))
+
+	assert.Nil(t, ReplayFile(files[0]))
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, "Span", received[0].Type)
+	assert.Equal(t, "test-api-key", received[0].APIKey)
+	assert.Equal(t, 12345678901.0, received[0].Data.(map[string]interface{})["duration"])
+	assert.Equal(t, "Invocation", received[1].Type)
+}
+
+func TestReplayFileWithInvalidLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "thundra")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.ndjson")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{\"type\":\"Span\"}\nnot json\n"), 0644))
+
+	err = ReplayFile(path)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "data.ndjson:2")
+}
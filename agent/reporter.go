@@ -1,16 +1,15 @@
 package agent
 
 import (
-	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
-	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 
@@ -18,7 +17,11 @@ import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 )
 
-type reporter interface {
+// Reporter collects the monitoring data produced by the plugins during an invocation
+// and sends it to its destination when Report is called. Reported returns the flag
+// which is set once the data of the current invocation is reported and FlushFlag
+// clears it for the next invocation.
+type Reporter interface {
 	Collect(messages []plugin.MonitoringDataWrapper)
 	Report()
 	ClearData()
@@ -26,48 +29,178 @@ type reporter interface {
 	FlushFlag()
 }
 
+// settingsReporter is implemented by the reporters which report the data of an invocation with the
+// settings of the agent. Collect and Report of these reporters use the current settings.
+type settingsReporter interface {
+	collectWith(s *config.Settings, messages []plugin.MonitoringDataWrapper)
+	reportWith(s *config.Settings)
+}
+
+// collectWith passes the messages to the reporter to be reported with the given settings
+func collectWith(r Reporter, s *config.Settings, messages []plugin.MonitoringDataWrapper) {
+	if sr, ok := r.(settingsReporter); ok {
+		sr.collectWith(s, messages)
+		return
+	}
+	r.Collect(messages)
+}
+
+// reportWith makes the reporter report the collected data with the given settings
+func reportWith(r Reporter, s *config.Settings) {
+	if sr, ok := r.(settingsReporter); ok {
+		sr.reportWith(s)
+		return
+	}
+	r.Report()
+}
+
 type reporterImpl struct {
 	messageQueue []plugin.MonitoringDataWrapper
 	client       *http.Client
 	reported     *uint32
 }
 
-var collectorURL string
 var mutex = &sync.Mutex{}
 
-func init() {
-	if url := os.Getenv(constants.ThundraLambdaReportRestBaseURL); url != "" {
-		collectorURL = url
-	} else {
-		collectorURL = config.CollectorUrl
+// currentSettings returns a copy of the current settings
+func currentSettings() *config.Settings {
+	s := config.CurrentSettings()
+	return &s
+}
+
+// withAPIKey returns a copy of the messages carrying the API key given in the settings
+func withAPIKey(s *config.Settings, messages []plugin.MonitoringDataWrapper) []plugin.MonitoringDataWrapper {
+	keyed := make([]plugin.MonitoringDataWrapper, len(messages))
+	for i, message := range messages {
+		message.APIKey = s.APIKey
+		keyed[i] = message
 	}
+	return keyed
+}
+
+// wrapCompositeData wraps the batch with the base data in a composite monitoring data
+func wrapCompositeData(s *config.Settings, batch []plugin.MonitoringDataWrapper) plugin.MonitoringDataWrapper {
+	baseData := plugin.PrepareBaseData()
+	compositeData := plugin.PrepareCompositeData(baseData, batch)
+	wrappedCompositeData := plugin.WrapMonitoringData(compositeData, "Composite")
+	wrappedCompositeData.APIKey = s.APIKey
+	return wrappedCompositeData
 }
 
 func newReporter() *reporterImpl {
 	return &reporterImpl{
-		client:   createHTTPClient(),
 		reported: new(uint32),
 	}
 }
 
+// httpClient returns the client of the reporter if it is given one, otherwise the client for the settings
+func (r *reporterImpl) httpClient(s *config.Settings) *http.Client {
+	if r.client != nil {
+		return r.client
+	}
+	return getHTTPClient(s)
+}
+
+// httpReporter sends the data to the Thundra collector regardless of the reporting mode
+type httpReporter struct {
+	*reporterImpl
+}
+
+// NewHTTPReporter returns a Reporter which sends the data to the Thundra REST collector
+func NewHTTPReporter() Reporter {
+	return &httpReporter{newReporter()}
+}
+
+// Collect collects the data from plugins
+func (r *httpReporter) Collect(messages []plugin.MonitoringDataWrapper) {
+	r.collectWith(currentSettings(), messages)
+}
+
+func (r *httpReporter) collectWith(s *config.Settings, messages []plugin.MonitoringDataWrapper) {
+	defer mutex.Unlock()
+	mutex.Lock()
+	r.messageQueue = append(r.messageQueue, withAPIKey(s, messages)...)
+}
+
+// Report sends the data to collector
+func (r *httpReporter) Report() {
+	r.reportWith(currentSettings())
+}
+
+func (r *httpReporter) reportWith(s *config.Settings) {
+	atomic.CompareAndSwapUint32(r.reported, 0, 1)
+	r.sendHTTPReq(s)
+}
+
+// cloudwatchReporter writes the data to stdout to be sent by CloudWatch Logs
+type cloudwatchReporter struct {
+	*reporterImpl
+}
+
+// NewCloudWatchReporter returns a Reporter which writes the data to stdout for async monitoring.
+// The data is written in composite form unless ReportCloudwatchCompositeDataEnabled is false.
+func NewCloudWatchReporter() Reporter {
+	return &cloudwatchReporter{newReporter()}
+}
+
+// Collect collects the data from plugins. If composite data is disabled, it writes the data immediately.
+func (r *cloudwatchReporter) Collect(messages []plugin.MonitoringDataWrapper) {
+	r.collectWith(currentSettings(), messages)
+}
+
+func (r *cloudwatchReporter) collectWith(s *config.Settings, messages []plugin.MonitoringDataWrapper) {
+	defer mutex.Unlock()
+	mutex.Lock()
+	if !s.ReportCloudwatchCompositeDataEnabled {
+		sendAsync(s, fitMessages(withAPIKey(s, messages), s.ReportCloudwatchMaxBytes))
+		return
+	}
+	r.messageQueue = append(r.messageQueue, withAPIKey(s, messages)...)
+}
+
+// Report writes the composite data to stdout
+func (r *cloudwatchReporter) Report() {
+	r.reportWith(currentSettings())
+}
+
+func (r *cloudwatchReporter) reportWith(s *config.Settings) {
+	atomic.CompareAndSwapUint32(r.reported, 0, 1)
+	if s.ReportCloudwatchCompositeDataEnabled {
+		r.sendAsyncComposite(s)
+	}
+}
+
 // Collect collects the data from plugins. If async is on, it sends the data immediately.
 func (r *reporterImpl) Collect(messages []plugin.MonitoringDataWrapper) {
+	r.collectWith(currentSettings(), messages)
+}
+
+func (r *reporterImpl) collectWith(s *config.Settings, messages []plugin.MonitoringDataWrapper) {
 	defer mutex.Unlock()
 	mutex.Lock()
-	if config.ReportCloudwatchEnabled && !config.ReportCloudwatchCompositeDataEnabled {
-		sendAsync(messages)
+	if s.ReportCloudwatchEnabled && !s.ReportCloudwatchCompositeDataEnabled &&
+		!s.ReportOTLPEnabled && !s.ReportZipkinEnabled {
+		sendAsync(s, fitMessages(withAPIKey(s, messages), s.ReportCloudwatchMaxBytes))
 		return
 	}
-	r.messageQueue = append(r.messageQueue, messages...)
+	r.messageQueue = append(r.messageQueue, withAPIKey(s, messages)...)
 }
 
 // Report sends the data to collector
 func (r *reporterImpl) Report() {
+	r.reportWith(currentSettings())
+}
+
+func (r *reporterImpl) reportWith(s *config.Settings) {
 	atomic.CompareAndSwapUint32(r.reported, 0, 1)
-	if !config.ReportCloudwatchEnabled {
-		r.sendHTTPReq()
-	} else if config.ReportCloudwatchCompositeDataEnabled {
-		r.sendAsyncComposite()
+	if s.ReportOTLPEnabled {
+		r.sendOTLP(s)
+	} else if s.ReportZipkinEnabled {
+		r.sendZipkin(s)
+	} else if !s.ReportCloudwatchEnabled {
+		r.sendHTTPReq(s)
+	} else if s.ReportCloudwatchCompositeDataEnabled {
+		r.sendAsyncComposite(s)
 	}
 }
 
@@ -86,10 +219,11 @@ func (r *reporterImpl) FlushFlag() {
 	atomic.CompareAndSwapUint32(r.Reported(), 1, 0)
 }
 
-func sendAsync(data []plugin.MonitoringDataWrapper) {
+func sendAsync(s *config.Settings, data []plugin.MonitoringDataWrapper) {
 	for i := range data {
-		b, err := json.Marshal(data[i])
+		b, err := marshalAsync(s, data[i])
 		if err != nil {
+			recordSerializationError()
 			log.Println(err)
 			return
 		}
@@ -97,105 +231,221 @@ func sendAsync(data []plugin.MonitoringDataWrapper) {
 	}
 }
 
-func (r *reporterImpl) sendAsyncComposite() {
-	batchSize := config.ReportCloudwatchCompositeBatchSize
-	for i := 0; i < len(r.messageQueue); i += batchSize {
-		end := i + batchSize
-		if end > len(r.messageQueue) {
-			end = len(r.messageQueue)
-		}
-		baseData := plugin.PrepareBaseData()
-		compositeData := plugin.PrepareCompositeData(baseData, r.messageQueue[i:end])
-		wrappedCompositeData := plugin.WrapMonitoringData(compositeData, "Composite")
-		sendAsync([]plugin.MonitoringDataWrapper{wrappedCompositeData})
+func (r *reporterImpl) sendAsyncComposite(s *config.Settings) {
+	batches := batchMessages(r.messageQueue, s.ReportCloudwatchCompositeBatchSize,
+		s.ReportCloudwatchMaxBytes, compositeOverhead(), true)
+	for _, batch := range batches {
+		sendAsync(s, []plugin.MonitoringDataWrapper{wrapCompositeData(s, batch)})
 	}
 }
 
-func (r *reporterImpl) sendHTTPReq() {
-	if config.DebugEnabled {
+func (r *reporterImpl) sendHTTPReq(s *config.Settings) {
+	if s.DebugEnabled {
 		log.Printf("MessageQueue:\n %+v \n", r.messageQueue)
 	}
-	targetURL := collectorURL + constants.MonitoringDataPath
-	if config.ReportRestCompositeDataEnabled {
-		targetURL = collectorURL + constants.CompositeMonitoringDataPath
+	targetURL := s.CollectorUrl + constants.MonitoringDataPath
+	if s.ReportRestCompositeDataEnabled {
+		targetURL = s.CollectorUrl + constants.CompositeMonitoringDataPath
 	}
 
-	if config.DebugEnabled {
+	if s.DebugEnabled {
 		log.Println("Sending HTTP request to Thundra collector: " + targetURL)
 	}
 
-	batchSize := config.ReportRestCompositeBatchSize
-	var wg sync.WaitGroup
-	for i := 0; i < len(r.messageQueue); i += batchSize {
-
-		end := i + batchSize
-
-		if end > len(r.messageQueue) {
-			end = len(r.messageQueue)
-		}
-		if config.ReportRestCompositeDataEnabled {
-			baseData := plugin.PrepareBaseData()
-			compositeData := plugin.PrepareCompositeData(baseData, r.messageQueue[i:end])
-			wrappedCompositeData := plugin.WrapMonitoringData(compositeData, "Composite")
+	var batches [][]plugin.MonitoringDataWrapper
+	if s.ReportRestCompositeDataEnabled {
+		batches = batchMessages(r.messageQueue, s.ReportRestCompositeBatchSize,
+			s.ReportRestMaxBytes, compositeOverhead(), true)
+	} else {
+		// Non-composite batches are JSON arrays of the messages
+		batches = batchMessages(r.messageQueue, s.ReportRestCompositeBatchSize,
+			s.ReportRestMaxBytes, len("[]"), false)
+	}
 
-			b, err := json.Marshal(wrappedCompositeData)
+	var wg sync.WaitGroup
+	for _, batch := range batches {
+		if s.ReportRestCompositeDataEnabled {
+			contentType, b, err := marshalCollectorBody(s, wrapCompositeData(s, batch))
 			if err != nil {
+				recordSerializationError()
 				log.Println("Error in marshalling ", err)
 				return
 			}
 			wg.Add(1)
-			go r.sendBatch(targetURL, b, &wg)
+			go r.sendBatch(s, newCollectorBatch(s, targetURL, contentType, b), &wg)
 		} else {
-			b, err := json.Marshal(r.messageQueue[i:end])
+			contentType, b, err := marshalCollectorBody(s, batch)
 			if err != nil {
+				recordSerializationError()
 				log.Println("Error in marshalling ", err)
 				return
 			}
 			wg.Add(1)
-			go r.sendBatch(targetURL, b, &wg)
+			go r.sendBatch(s, newCollectorBatch(s, targetURL, contentType, b), &wg)
 		}
 	}
 	wg.Wait()
 }
 
-func (r *reporterImpl) sendBatch(targetURL string, messages []byte, wg *sync.WaitGroup) {
+// marshalCollectorBody encodes the composite data or the batch of messages in the configured
+// encoding and returns the content type of the body. JSON is used unless protobuf is enabled.
+func marshalCollectorBody(s *config.Settings, v interface{}) (string, []byte, error) {
+	if s.ReportRestProtobufEnabled {
+		switch v := v.(type) {
+		case plugin.MonitoringDataWrapper:
+			return protobufContentType, v.MarshalProto(nil), nil
+		case []plugin.MonitoringDataWrapper:
+			return protobufContentType, plugin.MarshalProtoList(nil, v), nil
+		}
+	}
+	b, err := json.Marshal(v)
+	return jsonContentType, b, err
+}
+
+// sendBatch sends the batch with retries and spools it to be sent on the next invocation if it fails
+func (r *reporterImpl) sendBatch(s *config.Settings, batch collectorBatch, wg *sync.WaitGroup) {
 	defer wg.Done()
-	req, err := http.NewRequest("POST", targetURL, bytes.NewBuffer(messages))
-	if err != nil {
-		log.Println("Error http.NewRequest:", err)
-		return
+	if err := r.sendWithRetry(s, batch); err != nil && err != errReportingDisabled {
+		spoolBatch(s, batch)
 	}
-	req.Close = true
-	req.Header.Set("Authorization", "ApiKey "+config.APIKey)
-	req.Header.Set("Content-Type", "application/json")
+}
+
+// sendWithRetry sends the batch to the collector. Failed requests are retried with exponential
+// backoff as long as the retry count is not exceeded and the backoff fits in the remaining time.
+func (r *reporterImpl) sendWithRetry(s *config.Settings, batch collectorBatch) error {
+	backoff := s.ReportRestRetryBackoff
+	for attempt := 0; ; attempt++ {
+		req, err := batch.newRequest(s)
+		if err != nil {
+			log.Println("Error http.NewRequest:", err)
+			return err
+		}
+		if err = r.doRequest(s, req); err == nil {
+			recordSentBatch()
+			return nil
+		} else if err == errReportingDisabled {
+			return err
+		}
+		if attempt >= s.ReportRestRetryCount || !fitsInRemainingTime(backoff) {
+			return err
+		}
+		recordRetry()
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
 
-	resp, err := r.client.Do(req)
+// doRequest sends the request and returns an error if the request fails or the server returns 5xx
+func (r *reporterImpl) doRequest(s *config.Settings, req *http.Request) error {
+	client := r.httpClient(s)
+	if client == nil {
+		return errReportingDisabled
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
+		recordFailedRequest()
 		log.Println("Error client.Do(req):", err)
-		return
+		return err
 	}
-	if config.DebugEnabled {
+	recordResponse(resp.StatusCode, time.Since(start))
+	if s.DebugEnabled {
 		log.Println("response Status:", resp.Status)
 		log.Println("response Headers:", resp.Header)
 	}
-	if resp.Body == nil {
-		return
+	if resp.Body != nil {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			log.Println("ioutil.ReadAll(resp.Body): ", err)
+		} else if s.DebugEnabled {
+			log.Println("response Body:", string(body))
+		}
+		resp.Body.Close()
 	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Println("ioutil.ReadAll(resp.Body): ", err)
-	} else if config.DebugEnabled {
-		log.Println("response Body:", string(body))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		log.Println("Error response from " + req.URL.String() + ": " + resp.Status)
+		return fmt.Errorf("server responded with %s", resp.Status)
 	}
+	return nil
+}
 
-	resp.Body.Close()
+// httpClientKey holds the settings the HTTP clients are built with
+type httpClientKey struct {
+	connectTimeout time.Duration
+	timeout        time.Duration
+	trustAll       bool
+	caBundle       string
+	clientCert     string
+	clientKey      string
+}
+
+var httpClients = map[httpClientKey]*http.Client{}
+var httpClientsMutex sync.Mutex
+
+// getHTTPClient returns the HTTP client for the connection settings in s. The client is shared by the reporters
+// with the same connection settings and the connections are kept alive to be reused by the next invocations
+// of the warm container. It returns nil if the TLS configuration is invalid, so that no data is reported.
+func getHTTPClient(s *config.Settings) *http.Client {
+	key := httpClientKey{
+		connectTimeout: s.ReportRestConnectTimeout,
+		timeout:        s.ReportRestTimeout,
+		trustAll:       s.TrustAllCertificates,
+		caBundle:       s.ReportRestCABundle,
+		clientCert:     s.ReportRestClientCert,
+		clientKey:      s.ReportRestClientKey,
+	}
+	httpClientsMutex.Lock()
+	defer httpClientsMutex.Unlock()
+	if client, ok := httpClients[key]; ok {
+		return client
+	}
+	client, err := createHTTPClient(s)
+	if err != nil {
+		log.Println("Thundra reporting is disabled as the collector TLS configuration is invalid:", err)
+	}
+	// The invalid configurations are kept as well to not log the error again
+	httpClients[key] = client
+	return client
 }
 
-func createHTTPClient() *http.Client {
+func createHTTPClient(s *config.Settings) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(s)
+	if err != nil {
+		return nil, err
+	}
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: config.TrustAllCertificates,
-		},
+		// HTTPS_PROXY, HTTP_PROXY and NO_PROXY are respected
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   s.ReportRestConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: s.ReportRestConnectTimeout,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
 	}
-	return &http.Client{Transport: tr}
+	return &http.Client{
+		Transport: tr,
+		Timeout:   s.ReportRestTimeout,
+	}, nil
+}
+
+// prewarmHTTPClient opens a connection to the collector so that the TLS handshake is done during the init phase
+func prewarmHTTPClient(client *http.Client, url string) {
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		log.Println("Error while pre-warming the collector connection:", err)
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Println("Error while pre-warming the collector connection:", err)
+		return
+	}
+	// The connection is put back to the pool once the body is read and closed
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
 }
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -102,3 +104,52 @@ func TestReportCompositeDisabled(t *testing.T) {
 	testReporter.Report()
 	test.CleanEnvironment()
 }
+
+func TestReportProtobuf(t *testing.T) {
+	config.ReportRestProtobufEnabled = true
+	defer func() { config.ReportRestProtobufEnabled = false }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	messages := []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")}
+	var requests int32
+	r := &httpReporter{newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "application/x-protobuf", req.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(req.Body)
+		// The body starts with the data model version field of the MonitoringData message
+		assert.Equal(t, byte(1<<3|2), body[0])
+		assert.Contains(t, string(body), "Invocation")
+		return &(http.Response{}), nil
+	})}
+	r.messageQueue = messages
+	r.Report()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestMarshalCollectorBodyDefaultsToJSON(t *testing.T) {
+	messages := []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")}
+	contentType, b, err := marshalCollectorBody(currentSettings(), messages)
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", contentType)
+	var data []plugin.MonitoringDataWrapper
+	assert.Nil(t, json.Unmarshal(b, &data))
+	assert.Equal(t, "Invocation", data[0].Type)
+}
+
+func TestHTTPReporterReportsWhenCloudwatchEnabled(t *testing.T) {
+	config.ReportCloudwatchEnabled = true
+	defer func() { config.ReportCloudwatchEnabled = false }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var requests int32
+	r := &httpReporter{newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return &(http.Response{Body: ioutil.NopCloser(strings.NewReader(""))}), nil
+	})}
+	r.Collect([]plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(mockData, "Invocation")})
+	assert.Equal(t, 1, len(r.messageQueue))
+	r.Report()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+const spoolFileExtension = ".batch"
+
+const (
+	jsonContentType     = "application/json"
+	protobufContentType = "application/x-protobuf"
+)
+
+// droppedBatches counts the collector batches which could neither be sent nor spooled
+var droppedBatches uint64
+
+// reportDeadline is the deadline of the current invocation in unix nanoseconds, 0 if unknown
+var reportDeadline int64
+
+var spoolMutex = &sync.Mutex{}
+
+// Protocols of the batches which are not sent to the Thundra collector. OTLP batches are sent with
+// the configured OTLP headers instead of the Thundra API key, and Zipkin batches without credentials.
+const (
+	otlpProtocol   = "otlp"
+	zipkinProtocol = "zipkin"
+)
+
+// collectorBatch is a request body to be sent to the collector. It is also the format of the spool files.
+type collectorBatch struct {
+	URL             string `json:"url"`
+	Protocol        string `json:"protocol,omitempty"`    // Thundra collector if empty
+	ContentType     string `json:"contentType,omitempty"` // JSON if empty
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	Body            []byte `json:"body"`
+}
+
+// newCollectorBatch returns the batch with the given body which is compressed if it exceeds the compression threshold
+func newCollectorBatch(s *config.Settings, url string, contentType string, body []byte) collectorBatch {
+	batch := collectorBatch{URL: url, ContentType: contentType, Body: body}
+	if shouldCompress(s, len(body)) {
+		if gz, err := gzipBytes(body); err != nil {
+			log.Println("Error in compressing monitoring data:", err)
+		} else {
+			batch.Body = gz
+			batch.ContentEncoding = "gzip"
+		}
+	}
+	return batch
+}
+
+func (b collectorBatch) newRequest(s *config.Settings) (*http.Request, error) {
+	req, err := http.NewRequest("POST", b.URL, bytes.NewReader(b.Body))
+	if err != nil {
+		return nil, err
+	}
+	if b.ContentType != "" {
+		req.Header.Set("Content-Type", b.ContentType)
+	} else {
+		req.Header.Set("Content-Type", jsonContentType)
+	}
+	if b.ContentEncoding != "" {
+		req.Header.Set("Content-Encoding", b.ContentEncoding)
+	}
+	// The credentials are not spooled, they are read from the settings when the batch is sent
+	switch b.Protocol {
+	case otlpProtocol:
+		for k, v := range s.ReportOTLPHeaders {
+			req.Header.Set(k, v)
+		}
+	case zipkinProtocol:
+	default:
+		req.Header.Set("Authorization", "ApiKey "+s.APIKey)
+	}
+	return req, nil
+}
+
+// setReportDeadline records the deadline of the invocation so that retries do not exceed it
+func setReportDeadline(ctx context.Context) {
+	var deadline int64
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d.UnixNano()
+	}
+	atomic.StoreInt64(&reportDeadline, deadline)
+}
+
+func fitsInRemainingTime(d time.Duration) bool {
+	deadline := atomic.LoadInt64(&reportDeadline)
+	return deadline == 0 || time.Now().Add(d).Before(time.Unix(0, deadline))
+}
+
+func dropBatch(reason string) {
+	atomic.AddUint64(&droppedBatches, 1)
+	log.Println("Dropped monitoring data batch:", reason)
+}
+
+// spoolBatch writes the batch under the spool directory to be sent on the next invocation.
+// The batch is dropped if the spool would exceed its maximum size.
+func spoolBatch(s *config.Settings, batch collectorBatch) {
+	if s.ReportSpoolMaxSize <= 0 {
+		dropBatch("spool is disabled")
+		return
+	}
+	b, err := json.Marshal(batch)
+	if err != nil {
+		dropBatch(err.Error())
+		return
+	}
+
+	spoolMutex.Lock()
+	defer spoolMutex.Unlock()
+	if err := os.MkdirAll(s.ReportSpoolDir, 0700); err != nil {
+		dropBatch(err.Error())
+		return
+	}
+	files, size, err := spoolFiles(s)
+	if err != nil {
+		dropBatch(err.Error())
+		return
+	}
+	if size+int64(len(b)) > int64(s.ReportSpoolMaxSize) {
+		dropBatch(fmt.Sprintf("spool is full with %d batches", len(files)))
+		return
+	}
+	name := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), utils.GenerateNewID(), spoolFileExtension)
+	if err := ioutil.WriteFile(filepath.Join(s.ReportSpoolDir, name), b, 0600); err != nil {
+		dropBatch(err.Error())
+	}
+}
+
+// spoolFiles returns the paths of the spooled batches in the order they are spooled and their total size
+func spoolFiles(s *config.Settings) ([]string, int64, error) {
+	infos, err := ioutil.ReadDir(s.ReportSpoolDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	var files []string
+	var size int64
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), spoolFileExtension) {
+			continue
+		}
+		files = append(files, filepath.Join(s.ReportSpoolDir, info.Name()))
+		size += info.Size()
+	}
+	sort.Strings(files)
+	return files, size, nil
+}
+
+// replaySpool sends the spooled batches to the collector. It stops at the first failure or when
+// a request may not complete before the deadline of the invocation, and keeps the remaining
+// batches for the next invocation.
+func (r *reporterImpl) replaySpool(s *config.Settings) {
+	spoolMutex.Lock()
+	defer spoolMutex.Unlock()
+	files, _, err := spoolFiles(s)
+	if err != nil {
+		log.Println("Error while reading the spool:", err)
+		return
+	}
+	for _, file := range files {
+		if !fitsInRemainingTime(s.ReportRestTimeout) {
+			return
+		}
+		var batch collectorBatch
+		b, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = json.Unmarshal(b, &batch)
+		}
+		if err != nil {
+			os.Remove(file)
+			dropBatch(err.Error())
+			continue
+		}
+		req, err := batch.newRequest(s)
+		if err != nil {
+			os.Remove(file)
+			dropBatch(err.Error())
+			continue
+		}
+		if r.doRequest(s, req) != nil {
+			return
+		}
+		os.Remove(file)
+	}
+}
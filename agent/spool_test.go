@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func prepareSpool(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "thundra-spool")
+	assert.Nil(t, err)
+	config.ReportSpoolDir = dir
+	config.ReportRestRetryBackoff = time.Millisecond
+	return func() {
+		os.RemoveAll(dir)
+		config.ReportSpoolDir = constants.DefaultReportSpoolDir
+		config.ReportSpoolMaxSize = constants.DefaultReportSpoolMaxSize
+		config.ReportRestRetryCount = constants.DefaultReportRestRetryCount
+		config.ReportRestRetryBackoff = constants.DefaultReportRestRetryBackoff * time.Millisecond
+		atomic.StoreUint64(&droppedBatches, 0)
+		atomic.StoreInt64(&reportDeadline, 0)
+	}
+}
+
+func sendTestBatch(r *reporterImpl) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	r.sendBatch(currentSettings(), newCollectorBatch(currentSettings(), "https://collector.thundra.io/v1/monitoring-data", jsonContentType,
+		[]byte(`[{"type":"Invocation"}]`)), &wg)
+	wg.Wait()
+}
+
+func TestSendBatchRetries(t *testing.T) {
+	defer prepareSpool(t)()
+
+	var requests int32
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	sendTestBatch(r)
+
+	assert.Equal(t, int32(3), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 0, len(files))
+}
+
+func TestSendBatchRetryRespectsDeadline(t *testing.T) {
+	defer prepareSpool(t)()
+	config.ReportRestRetryBackoff = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	setReportDeadline(ctx)
+
+	var requests int32
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return nil, errors.New("connection refused")
+	})
+	sendTestBatch(r)
+
+	assert.Equal(t, int32(1), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 1, len(files))
+}
+
+func TestSpoolAndReplay(t *testing.T) {
+	defer prepareSpool(t)()
+	config.ReportRestRetryCount = 1
+	config.ReportCompressionEnabled = true
+	config.ReportCompressionThreshold = 0
+	defer func() {
+		config.ReportCompressionEnabled = false
+		config.ReportCompressionThreshold = constants.DefaultReportCompressionThreshold
+	}()
+
+	var requests int32
+	failing := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return nil, errors.New("connection refused")
+	})
+	sendTestBatch(failing)
+	sendTestBatch(failing)
+	assert.Equal(t, int32(4), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 2, len(files))
+
+	var bodies []string
+	succeeding := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "https://collector.thundra.io/v1/monitoring-data", req.URL.String())
+		assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(gunzip(t, body)))
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	succeeding.replaySpool(currentSettings())
+
+	assert.Equal(t, []string{`[{"type":"Invocation"}]`, `[{"type":"Invocation"}]`}, bodies)
+	files, _, _ = spoolFiles(currentSettings())
+	assert.Equal(t, 0, len(files))
+	assert.Equal(t, uint64(0), atomic.LoadUint64(&droppedBatches))
+}
+
+func TestSpoolKeepsContentType(t *testing.T) {
+	defer prepareSpool(t)()
+
+	spoolBatch(currentSettings(), newCollectorBatch(currentSettings(), "https://collector.thundra.io/v1/monitoring-data", protobufContentType, []byte{0x0a, 0x00}))
+
+	var contentTypes []string
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		contentTypes = append(contentTypes, req.Header.Get("Content-Type"))
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	r.replaySpool(currentSettings())
+	assert.Equal(t, []string{"application/x-protobuf"}, contentTypes)
+
+	// Batches spooled before the content type is recorded are JSON
+	req, err := collectorBatch{URL: "https://collector.thundra.io/v1/monitoring-data", Body: []byte("[]")}.newRequest(currentSettings())
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+}
+
+func TestReplayStopsAtFirstFailure(t *testing.T) {
+	defer prepareSpool(t)()
+	spoolBatch(currentSettings(), collectorBatch{URL: "https://collector.thundra.io/v1/monitoring-data", Body: []byte("[]")})
+	spoolBatch(currentSettings(), collectorBatch{URL: "https://collector.thundra.io/v1/monitoring-data", Body: []byte("[]")})
+
+	var requests int32
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, nil
+	})
+	r.replaySpool(currentSettings())
+
+	assert.Equal(t, int32(1), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 2, len(files))
+}
+
+func TestSpoolFullDropsBatch(t *testing.T) {
+	defer prepareSpool(t)()
+	config.ReportSpoolMaxSize = 150
+
+	batch := collectorBatch{URL: "https://collector.thundra.io/v1/monitoring-data", Body: []byte(`[{"type":"Invocation"}]`)}
+	spoolBatch(currentSettings(), batch)
+	spoolBatch(currentSettings(), batch)
+
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&droppedBatches))
+}
+
+func TestExecutePreHooksSetsDroppedBatchCount(t *testing.T) {
+	defer prepareSpool(t)()
+	defer func() { plugin.DroppedBatchCount = 0 }()
+	atomic.StoreUint64(&droppedBatches, 2)
+
+	a := New().SetReporter(test.NewMockReporter())
+	a.ExecutePreHooks(context.TODO(), createRawMessage())
+
+	assert.Equal(t, uint64(2), plugin.DroppedBatchCount)
+}
+
+func TestReplaySpoolRespectsDeadline(t *testing.T) {
+	defer prepareSpool(t)()
+	spoolBatch(currentSettings(), collectorBatch{URL: "https://collector.thundra.io/v1/monitoring-data", Body: []byte("[]")})
+	ctx, cancel := context.WithTimeout(context.Background(), config.ReportRestTimeout/2)
+	defer cancel()
+	setReportDeadline(ctx)
+
+	var requests int32
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	r.replaySpool(currentSettings())
+
+	assert.Equal(t, int32(0), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 1, len(files))
+}
+
+func TestSpoolIsReplayedAfterReport(t *testing.T) {
+	defer prepareSpool(t)()
+	spoolBatch(currentSettings(), collectorBatch{URL: "https://collector.thundra.io/v1/monitoring-data", Body: []byte("[]")})
+
+	var requests int32
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	a := New().SetReporter(r)
+	ctx := a.ExecutePreHooks(context.TODO(), createRawMessage())
+	assert.Equal(t, int32(0), requests)
+
+	a.ExecutePostHooks(ctx, createRawMessage(), nil, nil)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 0, len(files))
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// statsdMaxPacketSize keeps the datagrams small enough to not be fragmented
+const statsdMaxPacketSize = 1432
+
+// statsdSkippedTags are the invocation tags which are unique to each
+// invocation or free-form, so they are not sent as metric tags
+var statsdSkippedTags = map[string]bool{
+	constants.AwsLambdaInvocationRequestId: true,
+	constants.AwsLambdaLogStreamName:       true,
+	constants.AwsLambdaMemoryUsage:         true,
+	constants.AwsXRayTraceID:               true,
+	constants.AwsXRaySegmentID:             true,
+	constants.AwsErrorMessage:              true,
+}
+
+// sendStatsD sends the metric plugin values and the invocation counters in the
+// given monitoring data to the StatsD endpoint. Tags are only sent to DogStatsD.
+func sendStatsD(s *config.Settings, messages []plugin.MonitoringDataWrapper) {
+	lines := statsdLines(s, messages)
+	if len(lines) == 0 {
+		return
+	}
+
+	conn, err := net.Dial("udp", s.ReportStatsDAddress)
+	if err != nil {
+		log.Println("Error while connecting to the StatsD endpoint:", err)
+		return
+	}
+	defer conn.Close()
+
+	var packet bytes.Buffer
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > statsdMaxPacketSize {
+			writeStatsDPacket(conn, packet.Bytes())
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	writeStatsDPacket(conn, packet.Bytes())
+}
+
+func writeStatsDPacket(conn net.Conn, packet []byte) {
+	if _, err := conn.Write(packet); err != nil {
+		log.Println("Error while sending StatsD metrics:", err)
+	}
+}
+
+func statsdLines(s *config.Settings, messages []plugin.MonitoringDataWrapper) []string {
+	var lines []string
+	for i := range messages {
+		if messages[i].Type != metricDataType && messages[i].Type != invocationDataType {
+			continue
+		}
+		md, err := decodeMonitoringData(messages[i].Data)
+		if err != nil {
+			log.Println("Error in converting monitoring data to StatsD:", err)
+			continue
+		}
+
+		switch messages[i].Type {
+		case metricDataType:
+			tags := statsdTags(md.Tags)
+			names := make([]string, 0, len(md.Metrics))
+			for name := range md.Metrics {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if value, ok := md.Metrics[name].(json.Number); ok {
+					lines = append(lines, statsdLine(s, name, value.String(), "g", tags))
+				}
+			}
+		case invocationDataType:
+			tags := statsdTags(md.Tags)
+			lines = append(lines, statsdLine(s, "invocation.duration", fmt.Sprint(md.Duration), "ms", tags))
+			lines = append(lines, statsdLine(s, "invocation.count", "1", "c", tags))
+			if md.Erroneous {
+				lines = append(lines, statsdLine(s, "invocation.error", "1", "c", tags))
+			}
+			if md.ColdStart {
+				lines = append(lines, statsdLine(s, "invocation.coldstart", "1", "c", tags))
+			}
+			if md.Timeout {
+				lines = append(lines, statsdLine(s, "invocation.timeout", "1", "c", tags))
+			}
+		}
+	}
+	return lines
+}
+
+func statsdLine(s *config.Settings, name string, value string, metricType string, tags []string) string {
+	line := s.ReportStatsDPrefix + statsdSanitize(name) + ":" + value + "|" + metricType
+	if s.ReportStatsDFlavor == "dogstatsd" && len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
+
+// statsdTags returns the application tags and the given tags in the name:value form
+func statsdTags(tags map[string]interface{}) []string {
+	all := map[string]interface{}{}
+	for k, v := range application.ApplicationTags {
+		all[k] = v
+	}
+	for k, v := range tags {
+		if !statsdSkippedTags[k] {
+			all[k] = v
+		}
+	}
+
+	var result []string
+	for k, v := range all {
+		switch v.(type) {
+		case string, bool, json.Number, int, int64, float64:
+			result = append(result, statsdSanitize(k)+":"+statsdTagValueReplacer.Replace(fmt.Sprint(v)))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+var statsdReplacer = strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "@", "_", "\n", "_", " ", "_")
+
+// Tag values can contain colons as the tag name ends at the first one
+var statsdTagValueReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_")
+
+func statsdSanitize(s string) string {
+	return statsdReplacer.Replace(s)
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func readStatsDLines(t *testing.T, conn net.PacketConn) []string {
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.Nil(t, err)
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+func TestSendStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+	config.ReportStatsDAddress = conn.LocalAddr().String()
+	application.ApplicationTags = map[string]interface{}{"team": "payments"}
+	defer func() { application.ApplicationTags = map[string]interface{}{} }()
+
+	invocation := map[string]interface{}{
+		"duration":  120,
+		"erroneous": true,
+		"coldStart": true,
+		"tags": map[string]interface{}{
+			"aws.lambda.name":                  "test-function",
+			"aws.lambda.arn":                   "arn:aws:lambda:us-west-2:123456789012:function:test",
+			"aws.lambda.invocation.request_id": "request-id",
+			"error.kind":                       "errorString",
+			"error.message":                    "user 42 not found",
+		},
+	}
+	metric := map[string]interface{}{
+		"metrics": map[string]interface{}{"numGoroutine": 12},
+		"tags":    map[string]interface{}{"aws.region": "us-west-2"},
+	}
+	sendStatsD(currentSettings(), []plugin.MonitoringDataWrapper{
+		plugin.WrapMonitoringData(metric, "Metric"),
+		plugin.WrapMonitoringData(invocation, "Invocation"),
+		plugin.WrapMonitoringData(map[string]interface{}{"id": "span-id"}, "Span"),
+	})
+
+	// The error kind is sent while the error message is skipped
+	invocationTags := "|#aws.lambda.arn:arn:aws:lambda:us-west-2:123456789012:function:test,aws.lambda.name:test-function,error.kind:errorString,team:payments"
+	assert.Equal(t, []string{
+		"thundra.numGoroutine:12|g|#aws.region:us-west-2,team:payments",
+		"thundra.invocation.duration:120|ms" + invocationTags,
+		"thundra.invocation.count:1|c" + invocationTags,
+		"thundra.invocation.error:1|c" + invocationTags,
+		"thundra.invocation.coldstart:1|c" + invocationTags,
+	}, readStatsDLines(t, conn))
+}
+
+func TestSendStatsDWithoutTags(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+	config.ReportStatsDAddress = conn.LocalAddr().String()
+	config.ReportStatsDFlavor = "statsd"
+	defer func() { config.ReportStatsDFlavor = "dogstatsd" }()
+
+	invocation := map[string]interface{}{
+		"duration": 25,
+		"tags":     map[string]interface{}{"aws.lambda.name": "test-function"},
+	}
+	sendStatsD(currentSettings(), []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(invocation, "Invocation")})
+
+	assert.Equal(t, []string{
+		"thundra.invocation.duration:25|ms",
+		"thundra.invocation.count:1|c",
+	}, readStatsDLines(t, conn))
+}
+
+type localOnlyPlugin struct {
+	MockPlugin
+}
+
+func (p *localOnlyPlugin) AfterExecution(ctx context.Context, request json.RawMessage, response interface{}, err interface{}) ([]plugin.MonitoringDataWrapper, context.Context) {
+	metric := plugin.WrapMonitoringData(map[string]interface{}{
+		"metrics": map[string]interface{}{"numGoroutine": 12},
+	}, "Metric")
+	metric.LocalOnly = true
+	return []plugin.MonitoringDataWrapper{metric}, ctx
+}
+
+func TestLocalOnlyDataIsSentToStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+	config.ReportStatsDEnabled = true
+	config.ReportStatsDAddress = conn.LocalAddr().String()
+	defer func() { config.ReportStatsDEnabled = false }()
+
+	r := test.NewMockReporter()
+	New().AddPlugin(&localOnlyPlugin{}).SetReporter(r).ExecutePostHooks(context.TODO(), createRawMessage(), nil, nil)
+
+	assert.Equal(t, []string{"thundra.numGoroutine:12|g"}, readStatsDLines(t, conn))
+	assert.Equal(t, 0, len(r.MessageQueue))
+}
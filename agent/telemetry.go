@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+// sendLatencyBuckets are the upper bounds of the send latency histogram buckets in milliseconds.
+// The requests taking longer than the last bound are counted in an additional bucket.
+var sendLatencyBuckets = []int64{10, 50, 100, 250, 500, 1000}
+
+// reporterStats counts what happened to the monitoring data since the start of the container.
+// The counters are attached to the data of the next invocation as agent tags so that the
+// invocations whose data could not be reported are visible.
+type reporterStats struct {
+	collectedMessages   uint64
+	droppedMessages     uint64
+	sentBatches         uint64
+	retries             uint64
+	failedRequests      uint64
+	serializationErrors uint64
+	// Indexed by the status code divided by 100
+	statusClasses [6]uint64
+	latencies     [7]uint64
+}
+
+var stats reporterStats
+
+func recordCollected(count int) {
+	atomic.AddUint64(&stats.collectedMessages, uint64(count))
+}
+
+func recordDroppedMessage() {
+	atomic.AddUint64(&stats.droppedMessages, 1)
+}
+
+func recordSentBatch() {
+	atomic.AddUint64(&stats.sentBatches, 1)
+}
+
+func recordRetry() {
+	atomic.AddUint64(&stats.retries, 1)
+}
+
+func recordFailedRequest() {
+	atomic.AddUint64(&stats.failedRequests, 1)
+}
+
+func recordSerializationError() {
+	atomic.AddUint64(&stats.serializationErrors, 1)
+}
+
+// recordResponse counts the status class of the response and the latency of the request
+func recordResponse(statusCode int, latency time.Duration) {
+	if class := statusCode / 100; class >= 1 && class < len(stats.statusClasses) {
+		atomic.AddUint64(&stats.statusClasses[class], 1)
+	}
+	ms := int64(latency / time.Millisecond)
+	bucket := len(sendLatencyBuckets)
+	for i, bound := range sendLatencyBuckets {
+		if ms <= bound {
+			bucket = i
+			break
+		}
+	}
+	atomic.AddUint64(&stats.latencies[bucket], 1)
+}
+
+// reporterTelemetry returns the non-zero counters keyed by their agent tag names
+func reporterTelemetry() map[string]interface{} {
+	tags := map[string]interface{}{}
+	counters := map[string]*uint64{
+		constants.ThundraAgentReportCollectedMessages:   &stats.collectedMessages,
+		constants.ThundraAgentReportDroppedMessages:     &stats.droppedMessages,
+		constants.ThundraAgentReportSentBatches:         &stats.sentBatches,
+		constants.ThundraAgentReportRetries:             &stats.retries,
+		constants.ThundraAgentReportFailedRequests:      &stats.failedRequests,
+		constants.ThundraAgentReportSerializationErrors: &stats.serializationErrors,
+	}
+	for tag, counter := range counters {
+		if v := atomic.LoadUint64(counter); v > 0 {
+			tags[tag] = v
+		}
+	}
+	statusClasses := map[string]uint64{}
+	for class := range stats.statusClasses {
+		if v := atomic.LoadUint64(&stats.statusClasses[class]); v > 0 {
+			statusClasses[fmt.Sprintf("%dxx", class)] = v
+		}
+	}
+	if len(statusClasses) > 0 {
+		tags[constants.ThundraAgentReportStatusClasses] = statusClasses
+	}
+	latencies := map[string]uint64{}
+	for i := range stats.latencies {
+		if v := atomic.LoadUint64(&stats.latencies[i]); v > 0 {
+			latencies[latencyBucketName(i)] = v
+		}
+	}
+	if len(latencies) > 0 {
+		tags[constants.ThundraAgentReportSendLatency] = latencies
+	}
+	return tags
+}
+
+func latencyBucketName(i int) string {
+	if i < len(sendLatencyBuckets) {
+		return fmt.Sprintf("le_%dms", sendLatencyBuckets[i])
+	}
+	return fmt.Sprintf("gt_%dms", sendLatencyBuckets[len(sendLatencyBuckets)-1])
+}
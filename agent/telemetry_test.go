@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func resetReporterStats() {
+	stats = reporterStats{}
+	plugin.ReporterTelemetry = nil
+}
+
+func TestReporterTelemetryEmpty(t *testing.T) {
+	resetReporterStats()
+
+	assert.Empty(t, reporterTelemetry())
+}
+
+func TestReporterTelemetryCountsRequests(t *testing.T) {
+	defer prepareSpool(t)()
+	defer resetReporterStats()
+	resetReporterStats()
+	config.ReportRestRetryCount = 2
+
+	var requests int32
+	r := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1:
+			return nil, errors.New("connection refused")
+		case 2:
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	sendTestBatch(r)
+
+	telemetry := reporterTelemetry()
+	assert.Equal(t, uint64(1), telemetry[constants.ThundraAgentReportSentBatches])
+	assert.Equal(t, uint64(2), telemetry[constants.ThundraAgentReportRetries])
+	assert.Equal(t, uint64(1), telemetry[constants.ThundraAgentReportFailedRequests])
+	assert.Equal(t, map[string]uint64{"2xx": 1, "5xx": 1}, telemetry[constants.ThundraAgentReportStatusClasses])
+	assert.Equal(t, map[string]uint64{"le_10ms": 2}, telemetry[constants.ThundraAgentReportSendLatency])
+}
+
+func TestRecordResponseLatencyBuckets(t *testing.T) {
+	defer resetReporterStats()
+	resetReporterStats()
+
+	recordResponse(http.StatusOK, 5*time.Millisecond)
+	recordResponse(http.StatusOK, 100*time.Millisecond)
+	recordResponse(http.StatusBadRequest, 300*time.Millisecond)
+	recordResponse(http.StatusOK, 2*time.Second)
+
+	telemetry := reporterTelemetry()
+	assert.Equal(t, map[string]uint64{"2xx": 3, "4xx": 1}, telemetry[constants.ThundraAgentReportStatusClasses])
+	assert.Equal(t, map[string]uint64{"le_10ms": 1, "le_100ms": 1, "le_500ms": 1, "gt_1000ms": 1},
+		telemetry[constants.ThundraAgentReportSendLatency])
+}
+
+func TestReporterTelemetryCountsDroppedMessages(t *testing.T) {
+	defer resetReporterStats()
+	resetReporterStats()
+
+	data := map[string]interface{}{"id": "span-id", "operationName": string(make([]byte, 1000))}
+	fitMessages([]plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(data, "Span")}, 100)
+
+	assert.Equal(t, uint64(1), reporterTelemetry()[constants.ThundraAgentReportDroppedMessages])
+}
+
+func TestExecutePreHooksSetsReporterTelemetry(t *testing.T) {
+	defer resetReporterStats()
+	resetReporterStats()
+
+	a := New().SetReporter(test.NewMockReporter())
+	a.ExecutePreHooks(context.TODO(), createRawMessage())
+	assert.Empty(t, plugin.ReporterTelemetry)
+
+	recordSerializationError()
+	a.ExecutePreHooks(context.TODO(), createRawMessage())
+	assert.Equal(t, uint64(1), plugin.ReporterTelemetry[constants.ThundraAgentReportSerializationErrors])
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+)
+
+// errReportingDisabled is returned for the requests which are not sent as the TLS configuration is invalid
+var errReportingDisabled = errors.New("reporting is disabled as the TLS configuration is invalid")
+
+// newTLSConfig returns the TLS configuration of the collector connection with the CA bundle
+// and the client certificate given in the settings
+func newTLSConfig(s *config.Settings) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: s.TrustAllCertificates,
+	}
+
+	if s.ReportRestCABundle != "" {
+		caPEM, err := loadPEM(s.ReportRestCABundle)
+		if err != nil {
+			return nil, fmt.Errorf("can not read the CA bundle: %v", err)
+		}
+		// The CA bundle is trusted in addition to the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("the CA bundle does not contain any valid PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if s.ReportRestClientCert != "" || s.ReportRestClientKey != "" {
+		if s.ReportRestClientCert == "" || s.ReportRestClientKey == "" {
+			return nil, errors.New("both the client certificate and the client key should be set for mutual TLS")
+		}
+		certPEM, err := loadPEM(s.ReportRestClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("can not read the client certificate: %v", err)
+		}
+		keyPEM, err := loadPEM(s.ReportRestClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("can not read the client key: %v", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// loadPEM returns the given value if it is an inline PEM, otherwise it reads the PEM from the file at the given path
+func loadPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return ioutil.ReadFile(value)
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+)
+
+// newClientCertificate returns a self-signed client certificate and its key in PEM format
+func newClientCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "thundra-agent"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func serverCertificatePEM(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+func resetTLSConfig() {
+	config.ReportRestCABundle = ""
+	config.ReportRestClientCert = ""
+	config.ReportRestClientKey = ""
+}
+
+func sendTLSTestRequest(t *testing.T, url string) error {
+	client, err := createHTTPClient(currentSettings())
+	assert.Nil(t, err)
+	r := &reporterImpl{client: client, reported: new(uint32)}
+	req, _ := collectorBatch{URL: url, Body: []byte("[]")}.newRequest(currentSettings())
+	return r.doRequest(currentSettings(), req)
+}
+
+func TestCABundle(t *testing.T) {
+	defer resetTLSConfig()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	assert.NotNil(t, sendTLSTestRequest(t, server.URL))
+
+	config.ReportRestCABundle = serverCertificatePEM(server)
+	assert.Nil(t, sendTLSTestRequest(t, server.URL))
+
+	dir, err := ioutil.TempDir("", "thundra-tls")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ca.pem")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(serverCertificatePEM(server)), 0600))
+	config.ReportRestCABundle = path
+	assert.Nil(t, sendTLSTestRequest(t, server.URL))
+}
+
+func TestMutualTLS(t *testing.T) {
+	defer resetTLSConfig()
+	certPEM, keyPEM := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "thundra-agent", r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	config.ReportRestCABundle = serverCertificatePEM(server)
+
+	assert.NotNil(t, sendTLSTestRequest(t, server.URL))
+
+	config.ReportRestClientCert = string(certPEM)
+	config.ReportRestClientKey = string(keyPEM)
+	assert.Nil(t, sendTLSTestRequest(t, server.URL))
+}
+
+func TestInvalidTLSConfig(t *testing.T) {
+	defer resetTLSConfig()
+	certPEM, keyPEM := newClientCertificate(t)
+	otherCertPEM, _ := newClientCertificate(t)
+
+	tests := []struct {
+		caBundle   string
+		clientCert string
+		clientKey  string
+	}{
+		{caBundle: "-----BEGIN CERTIFICATE-----\ninvalid\n-----END CERTIFICATE-----\n"},
+		{caBundle: "/nonexistent/ca.pem"},
+		{clientCert: string(certPEM)},
+		{clientKey: string(keyPEM)},
+		{clientCert: string(otherCertPEM), clientKey: string(keyPEM)},
+		{clientCert: string(certPEM), clientKey: "/nonexistent/key.pem"},
+	}
+	for _, test := range tests {
+		config.ReportRestCABundle = test.caBundle
+		config.ReportRestClientCert = test.clientCert
+		config.ReportRestClientKey = test.clientKey
+		client, err := createHTTPClient(currentSettings())
+		assert.Nil(t, client)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestReportingDisabledWithoutClient(t *testing.T) {
+	defer prepareSpool(t)()
+	defer resetTLSConfig()
+	config.ReportRestCABundle = "/nonexistent/ca.pem"
+
+	r := &reporterImpl{reported: new(uint32)}
+	assert.Equal(t, errReportingDisabled, r.sendWithRetry(currentSettings(), collectorBatch{URL: "https://collector.thundra.io", Body: []byte("[]")}))
+	sendTestBatch(r)
+
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 0, len(files))
+	assert.Equal(t, uint64(0), droppedBatches)
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"reflect"
 
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
@@ -17,31 +16,32 @@ type lambdaFunction func(context.Context, json.RawMessage) (interface{}, error)
 // It wraps your lambda function and return a new lambda function. By that, AWS will be able to run this function
 // and Thundra will be able to collect monitoring data from your function.
 func (a *Agent) Wrap(handler interface{}) interface{} {
-	if config.ThundraDisabled {
+	if a.invocationSettings().ThundraDisabled {
 		return handler
 	}
 
 	if handler == nil {
-		return thundraErrorHandler(fmt.Errorf("handler is nil"))
+		return a.errorHandler(fmt.Errorf("handler is nil"))
 	}
 	handlerType := reflect.TypeOf(handler)
 	handlerValue := reflect.ValueOf(handler)
 
 	if handlerType.Kind() != reflect.Func {
-		return thundraErrorHandler(fmt.Errorf("handler kind %s is not %s", handlerType.Kind(), reflect.Func))
+		return a.errorHandler(fmt.Errorf("handler kind %s is not %s", handlerType.Kind(), reflect.Func))
 	}
 
 	takesContext, err := validateArguments(handlerType)
 
 	if err != nil {
-		return thundraErrorHandler(err)
+		return a.errorHandler(err)
 	}
 
 	if err := validateReturns(handlerType); err != nil {
-		return thundraErrorHandler(err)
+		return a.errorHandler(err)
 	}
 
 	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		ctx = a.invocationContext(ctx)
 		defer func() {
 			if err := recover(); err != nil {
 				a.ExecutePostHooks(ctx, payload, nil, err)
@@ -50,6 +50,7 @@ func (a *Agent) Wrap(handler interface{}) interface{} {
 		}()
 
 		if a.WarmUp && checkAndHandleWarmupRequest(payload) {
+			a.skipReport()
 			return nil, nil
 		}
 
@@ -64,6 +65,7 @@ func (a *Agent) Wrap(handler interface{}) interface{} {
 			newEvent := reflect.New(newEventType)
 
 			if err := json.Unmarshal(payload, newEvent.Interface()); err != nil {
+				a.skipReport()
 				return nil, err
 			}
 
@@ -106,8 +108,9 @@ func (a *Agent) Wrap(handler interface{}) interface{} {
 	}
 }
 
-func thundraErrorHandler(e error) lambdaFunction {
+func (a *Agent) errorHandler(e error) lambdaFunction {
 	return func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+		a.skipReport()
 		return nil, e
 	}
 }
@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+// zipkinRemoteHostTags are the tags which are looked up in order to find
+// the host of the remote endpoint of a span
+var zipkinRemoteHostTags = [][2]string{
+	{constants.HTTPTags["HOST"], ""},
+	{constants.DBTags["DB_HOST"], constants.DBTags["DB_PORT"]},
+	{constants.RedisTags["REDIS_HOST"], constants.RedisTags["REDIS_PORT"]},
+	{constants.AwsSDKTags["HOST"], ""},
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ID             string             `json:"id"`
+	ParentID       string             `json:"parentId,omitempty"`
+	Name           string             `json:"name"`
+	Kind           string             `json:"kind,omitempty"`
+	Timestamp      int64              `json:"timestamp"`
+	Duration       int64              `json:"duration"`
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint,omitempty"`
+	Annotations    []zipkinAnnotation `json:"annotations,omitempty"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+// sendZipkin converts the collected spans to Zipkin v2 spans and posts them
+// to the configured Zipkin URL. Other data types are not exported.
+func (r *reporterImpl) sendZipkin(s *config.Settings) {
+	spans := toZipkin(r.messageQueue)
+
+	if s.DebugEnabled {
+		log.Println("Sending Zipkin spans to: " + s.ReportZipkinURL)
+	}
+
+	var wg sync.WaitGroup
+	spanData := make([]interface{}, len(spans))
+	for i := range spans {
+		spanData[i] = spans[i]
+	}
+	// Zipkin spans are posted as JSON arrays
+	for _, batch := range batchConverted(s, spanDataType, spanData, len("[]")) {
+		for i := range batch {
+			batch[i] = zipkinTrimmedSpan(batch[i])
+		}
+		b, err := json.Marshal(batch)
+		if err != nil {
+			recordSerializationError()
+			log.Println("Error in marshalling ", err)
+			continue
+		}
+		wg.Add(1)
+		go r.sendZipkinBatch(s, b, &wg)
+	}
+	wg.Wait()
+}
+
+// zipkinTrimmedSpan joins the names of the truncated tags of a span trimmed to fit in a batch
+// since the values of the Zipkin tags are strings
+func zipkinTrimmedSpan(data interface{}) interface{} {
+	span, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+	if tags, ok := span["tags"].(map[string]interface{}); ok {
+		if truncatedTags, ok := tags[constants.ThundraAgentTruncatedTags].([]string); ok {
+			tags[constants.ThundraAgentTruncatedTags] = strings.Join(truncatedTags, ",")
+		}
+	}
+	return span
+}
+
+func (r *reporterImpl) sendZipkinBatch(s *config.Settings, spans []byte, wg *sync.WaitGroup) {
+	batch := newCollectorBatch(s, s.ReportZipkinURL, jsonContentType, spans)
+	batch.Protocol = zipkinProtocol
+	r.sendBatch(s, batch, wg)
+}
+
+func toZipkin(messages []plugin.MonitoringDataWrapper) []zipkinSpan {
+	var spans []zipkinSpan
+	localEndpoint := &zipkinEndpoint{ServiceName: application.ApplicationName}
+	for i := range messages {
+		if messages[i].Type != spanDataType {
+			continue
+		}
+		md, err := decodeMonitoringData(messages[i].Data)
+		if err != nil {
+			log.Println("Error in converting span data to Zipkin:", err)
+			continue
+		}
+		span := spanToZipkin(md)
+		span.LocalEndpoint = localEndpoint
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+func spanToZipkin(md monitoringData) zipkinSpan {
+	span := zipkinSpan{
+		TraceID:   utils.ToW3CTraceID(md.TraceID),
+		ID:        utils.ToW3CSpanID(md.ID),
+		Name:      md.OperationName,
+		Kind:      zipkinSpanKind(md),
+		Timestamp: md.StartTimestamp * 1000,
+		Duration:  (md.FinishTimestamp - md.StartTimestamp) * 1000,
+		Tags: map[string]string{
+			"thundra.domain_name":    md.DomainName,
+			"thundra.class_name":     md.ClassName,
+			"thundra.transaction_id": md.TransactionID,
+		},
+	}
+	if md.ParentSpanID != "" {
+		span.ParentID = utils.ToW3CSpanID(md.ParentSpanID)
+	}
+	if span.Kind == "CLIENT" || span.Kind == "PRODUCER" {
+		span.RemoteEndpoint = zipkinRemoteEndpoint(md)
+	}
+
+	for k, v := range md.Tags {
+		if s, ok := v.(string); ok {
+			span.Tags[k] = s
+		} else if b, err := json.Marshal(v); err == nil {
+			span.Tags[k] = string(b)
+		}
+	}
+	// Zipkin marks failed spans with an error tag holding the error message
+	if erroneous, _ := md.Tags[constants.AwsError].(bool); erroneous {
+		message, _ := md.Tags[constants.AwsErrorMessage].(string)
+		if message == "" {
+			message = "true"
+		}
+		span.Tags["error"] = message
+	}
+
+	for _, l := range md.Logs {
+		value := l.Name
+		if l.Value != nil {
+			value = fmt.Sprintf("%s=%v", l.Name, l.Value)
+		}
+		span.Annotations = append(span.Annotations, zipkinAnnotation{Timestamp: l.Timestamp * 1000, Value: value})
+	}
+	return span
+}
+
+// zipkinSpanKind returns SERVER for the root span and CLIENT or PRODUCER for the spans
+// which are marked as topology vertices, that is calls to other services
+func zipkinSpanKind(md monitoringData) string {
+	if md.ParentSpanID == "" {
+		return "SERVER"
+	}
+	if vertex, _ := md.Tags[constants.SpanTags["TOPOLOGY_VERTEX"]].(bool); !vertex {
+		return ""
+	}
+	if md.DomainName == constants.DomainNames["MESSAGING"] {
+		return "PRODUCER"
+	}
+	return "CLIENT"
+}
+
+func zipkinRemoteEndpoint(md monitoringData) *zipkinEndpoint {
+	endpoint := &zipkinEndpoint{ServiceName: strings.ToLower(md.ClassName)}
+	for _, tags := range zipkinRemoteHostTags {
+		host, ok := md.Tags[tags[0]].(string)
+		if !ok || host == "" {
+			continue
+		}
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host = h
+			endpoint.Port, _ = strconv.Atoi(p)
+		}
+		if tags[1] != "" && endpoint.Port == 0 {
+			endpoint.Port, _ = strconv.Atoi(fmt.Sprint(md.Tags[tags[1]]))
+		}
+		if ip := net.ParseIP(host); ip == nil {
+			endpoint.ServiceName = strings.ToLower(host)
+		} else if ip.To4() != nil {
+			endpoint.IPv4 = host
+		} else {
+			endpoint.IPv6 = host
+		}
+		break
+	}
+	return endpoint
+}
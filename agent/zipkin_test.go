@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+func TestReportZipkin(t *testing.T) {
+	config.ReportZipkinEnabled = true
+	config.ReportZipkinURL = "http://localhost:9411/api/v2/spans"
+	defer func() { config.ReportZipkinEnabled = false }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	dbSpan := map[string]interface{}{
+		"id":              "db-span-id",
+		"traceId":         otlpTestTraceID,
+		"parentSpanId":    otlpTestRootID,
+		"domainName":      "DB",
+		"className":       "MYSQL",
+		"operationName":   "users",
+		"startTimestamp":  1100,
+		"finishTimestamp": 1250,
+		"tags": map[string]interface{}{
+			"db.host":         "10.0.0.12",
+			"db.port":         "3306",
+			"topology.vertex": true,
+		},
+		"logs": map[string]interface{}{
+			"query": map[string]interface{}{"name": "rows", "value": 3, "timestamp": 1200},
+		},
+	}
+	messages := append(otlpTestMessages(), plugin.WrapMonitoringData(dbSpan, "Span"))
+
+	var spans []zipkinSpan
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/api/v2/spans", req.URL.Path)
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Nil(t, json.Unmarshal(body, &spans))
+		return &(http.Response{}), nil
+	})
+	testReporter.messageQueue = messages
+	testReporter.Report()
+
+	assert.Equal(t, 3, len(spans))
+
+	root := spans[0]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.TraceID)
+	assert.Equal(t, utils.ToW3CSpanID(otlpTestRootID), root.ID)
+	assert.Equal(t, "SERVER", root.Kind)
+	assert.Equal(t, int64(1000000), root.Timestamp)
+	assert.Equal(t, int64(500000), root.Duration)
+	assert.Equal(t, test.ApplicationName, root.LocalEndpoint.ServiceName)
+	assert.Nil(t, root.RemoteEndpoint)
+
+	httpSpan := spans[1]
+	assert.Equal(t, root.ID, httpSpan.ParentID)
+	assert.Equal(t, "", httpSpan.Kind)
+	assert.Equal(t, "Internal Server Error", httpSpan.Tags["error"])
+	assert.Equal(t, "500", httpSpan.Tags["http.status_code"])
+
+	db := spans[2]
+	assert.Equal(t, "CLIENT", db.Kind)
+	assert.Equal(t, &zipkinEndpoint{ServiceName: "mysql", IPv4: "10.0.0.12", Port: 3306}, db.RemoteEndpoint)
+	assert.Equal(t, []zipkinAnnotation{{Timestamp: 1200000, Value: "rows=3"}}, db.Annotations)
+}
+
+func TestReportZipkinBatchLimits(t *testing.T) {
+	config.ReportZipkinEnabled = true
+	maxBytes := config.ReportRestMaxBytes
+	defer func() {
+		config.ReportZipkinEnabled = false
+		config.ReportRestCompositeBatchSize = 100
+		config.ReportRestMaxBytes = maxBytes
+	}()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var lock sync.Mutex
+	var bodies [][]zipkinSpan
+	testReporter := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		var spans []zipkinSpan
+		assert.Nil(t, json.Unmarshal(body, &spans))
+		lock.Lock()
+		bodies = append(bodies, spans)
+		lock.Unlock()
+		return &(http.Response{}), nil
+	})
+
+	// The batch size is not limited if it is not positive
+	config.ReportRestCompositeBatchSize = 0
+	testReporter.messageQueue = otlpTestMessages()
+	testReporter.Report()
+	testReporter.ClearData()
+	assert.Equal(t, 1, len(bodies))
+	assert.Equal(t, 2, len(bodies[0]))
+
+	// The large tags are truncated to fit in the max bytes
+	bodies = nil
+	config.ReportRestMaxBytes = 2000
+	largeSpan := map[string]interface{}{
+		"id":              "large-span-id",
+		"traceId":         otlpTestTraceID,
+		"parentSpanId":    otlpTestRootID,
+		"operationName":   "large",
+		"startTimestamp":  1100,
+		"finishTimestamp": 1200,
+		"tags":            map[string]interface{}{"http.body": strings.Repeat("a", 5000)},
+	}
+	testReporter.messageQueue = []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(largeSpan, "Span")}
+	testReporter.Report()
+	assert.Equal(t, 1, len(bodies))
+	assert.Equal(t, "http.body", bodies[0][0].Tags[constants.ThundraAgentTruncatedTags])
+}
+
+func TestZipkinRemoteEndpointFromHTTPHost(t *testing.T) {
+	md := monitoringData{
+		ClassName: "HTTP",
+		Tags:      map[string]interface{}{"http.host": "API.example.com:8443"},
+	}
+	assert.Equal(t, &zipkinEndpoint{ServiceName: "api.example.com", Port: 8443}, zipkinRemoteEndpoint(md))
+}
+
+func TestReportZipkinRetriesAndSpools(t *testing.T) {
+	defer prepareSpool(t)()
+	config.ReportZipkinEnabled = true
+	config.ReportZipkinURL = "http://localhost:9411/api/v2/spans"
+	config.ReportRestRetryCount = 1
+	defer func() { config.ReportZipkinEnabled = false }()
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	var requests int32
+	failing := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&requests, 1)
+		return nil, errors.New("connection refused")
+	})
+	failing.messageQueue = otlpTestMessages()
+	failing.Report()
+
+	assert.Equal(t, int32(2), requests)
+	files, _, _ := spoolFiles(currentSettings())
+	assert.Equal(t, 1, len(files))
+
+	var spans []zipkinSpan
+	succeeding := newTestReporter(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/api/v2/spans", req.URL.Path)
+		assert.Empty(t, req.Header.Get("Authorization"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Nil(t, json.Unmarshal(body, &spans))
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	succeeding.replaySpool(currentSettings())
+
+	assert.Equal(t, 2, len(spans))
+	files, _, _ = spoolFiles(currentSettings())
+	assert.Equal(t, 0, len(files))
+}
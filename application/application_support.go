@@ -1,10 +1,10 @@
 package application
 
 import (
-	"os"
 	"strconv"
 	"strings"
 
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 )
 
@@ -12,7 +12,7 @@ func parseApplicationTags() {
 	clearApplicationTags()
 	tagPrefix := constants.ApplicationTagPrefixProp
 	prefixLen := len(tagPrefix)
-	for _, pair := range os.Environ() {
+	for _, pair := range config.Environ() {
 		if strings.HasPrefix(pair, tagPrefix) {
 			splits := strings.SplitN(pair[prefixLen:], "=", 2)
 			key, val := splits[0], splits[1]
@@ -45,7 +45,7 @@ func init() {
 
 // getApplicationDomainName returns application domain name
 func getApplicationDomainName() string {
-	v := os.Getenv(constants.ApplicationDomainProp)
+	v := config.Getenv(constants.ApplicationDomainProp)
 	if v != "" {
 		return v
 	}
@@ -54,7 +54,7 @@ func getApplicationDomainName() string {
 
 // getApplicationClassName returns application class name
 func getApplicationClassName() string {
-	v := os.Getenv(constants.ApplicationClassProp)
+	v := config.Getenv(constants.ApplicationClassProp)
 	if v != "" {
 		return v
 	}
@@ -63,7 +63,7 @@ func getApplicationClassName() string {
 
 // getApplicationName returns application name
 func getApplicationName() string {
-	v := os.Getenv(constants.ApplicationNameProp)
+	v := config.Getenv(constants.ApplicationNameProp)
 	if v != "" {
 		return v
 	}
@@ -76,7 +76,7 @@ func getFunctionName() string {
 }
 
 func GetApplicationID(ctx context.Context) string {
-	v := os.Getenv(constants.ApplicationIDProp)
+	v := config.Getenv(constants.ApplicationIDProp)
 	if v != "" {
 		return v
 	}
@@ -126,7 +126,7 @@ func getApplicationInstanceID() string {
 
 // getApplicationVersion returns function version
 func getApplicationVersion() string {
-	v := os.Getenv(constants.ApplicationVersionProp)
+	v := config.Getenv(constants.ApplicationVersionProp)
 	if v != "" {
 		return v
 	}
@@ -135,11 +135,11 @@ func getApplicationVersion() string {
 
 // getApplicationStage returns profile
 func getApplicationStage() string {
-	v := os.Getenv(constants.ApplicationStageProp)
+	v := config.Getenv(constants.ApplicationStageProp)
 	if v != "" {
 		return v
 	}
-	return os.Getenv(constants.ThundraApplicationStage)
+	return config.Getenv(constants.ThundraApplicationStage)
 }
 
 // getFunctionRegion returns AWS region's name
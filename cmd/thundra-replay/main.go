@@ -0,0 +1,32 @@
+// Command thundra-replay sends the monitoring data files written by the file reporter to the Thundra collector.
+// The API key and the collector are configured with the same environment variables as the agent.
+//
+//	thundra_apiKey=<api key> thundra-replay /tmp/thundra/*.ndjson
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/agent"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: thundra-replay FILE...")
+		os.Exit(2)
+	}
+	failed := false
+	for _, path := range os.Args[1:] {
+		if err := agent.ReplayFile(path); err != nil {
+			log.Printf("Error while replaying %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		log.Printf("Replayed %s\n", path)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
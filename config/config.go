@@ -2,7 +2,6 @@ package config
 
 import (
 	"log"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -25,9 +24,14 @@ var Http4xxErrorDisabled bool
 var Http5xxErrorDisabled bool
 var APIKey string
 var TrustAllCertificates bool
+var ReportRestCABundle string
+var ReportRestClientCert string
+var ReportRestClientKey string
 var MaskDynamoDBStatement bool
 var DynamoDBTraceInjectionEnabled bool
 var LambdaTraceInjectionDisabled bool
+var SQSTraceInjectionDisabled bool
+var SNSTraceInjectionDisabled bool
 var MaskRDBStatement bool
 var MaskEsBody bool
 var MaskRedisCommand bool
@@ -38,6 +42,7 @@ var MaskLambdaPayload bool
 var MaskHTTPBody bool
 var MaskAthenaStatement bool
 var SAMLocalDebugging bool
+var TracePropagationFormat string
 
 var MaskSESMail bool
 var MaskSESDestination bool
@@ -48,11 +53,55 @@ var TraceCloudwatchlogRequestEnabled bool
 
 var ReportRestCompositeBatchSize int
 var ReportCloudwatchCompositeBatchSize int
+var ReportRestMaxBytes int
+var ReportCloudwatchMaxBytes int
 
 var ReportRestCompositeDataEnabled bool
 var ReportCloudwatchCompositeDataEnabled bool
 var ReportCloudwatchEnabled bool
 
+var ReportOTLPEnabled bool
+var ReportOTLPEndpoint string
+var ReportOTLPHeaders map[string]string
+
+var ReportZipkinEnabled bool
+var ReportZipkinURL string
+
+var XRayEnabled bool
+var XRayDaemonAddress string
+
+var MetricEMFEnabled bool
+var MetricEMFNamespace string
+var MetricEMFDimensions []string
+
+var ReportStatsDEnabled bool
+var ReportStatsDAddress string
+var ReportStatsDPrefix string
+var ReportStatsDFlavor string
+
+var ReportCompressionEnabled bool
+var ReportCompressionThreshold int
+
+var ReportRestRetryCount int
+var ReportRestRetryBackoff time.Duration
+var ReportSpoolDir string
+var ReportSpoolMaxSize int
+
+var ExtensionEnabled bool
+
+var ReportRestTimeout time.Duration
+var ReportRestConnectTimeout time.Duration
+var ReportRestPrewarmEnabled bool
+
+var ReportFileEnabled bool
+var ReportFileDir string
+var ReportFileMaxSize int
+var ReportFileMaxInvocations int
+
+var ReportRestProtobufEnabled bool
+
+var SpanMaxLogs int
+
 var SamplingCountFrequency int
 var SamplingTimeFrequency int
 
@@ -61,10 +110,17 @@ var EsIntegrationUrlPathDepth int
 
 var AwsLambdaFunctionMemorySize int
 var AwsLambdaRegion string
+var AwsLambdaRuntimeAPI string
 
 var CollectorUrl string
 
 func init() {
+	loadConfigFile()
+	load()
+}
+
+// load sets the settings from the remote config, the env variables and the config file
+func load() {
 	ThundraDisabled = boolFromEnv(constants.ThundraLambdaDisable, false)
 	TraceDisabled = boolFromEnv(constants.ThundraDisableTrace, false)
 	MetricDisabled = boolFromEnv(constants.ThundraDisableMetric, true)
@@ -78,7 +134,11 @@ func init() {
 	Http5xxErrorDisabled = boolFromEnv(constants.ThundraDisableHttp5xxError, false)
 	APIKey = determineAPIKey()
 	LogLevel = determineLogLevel()
+	TracePropagationFormat = determineTracePropagationFormat()
 	TrustAllCertificates = boolFromEnv(constants.ThundraTrustAllCertificates, false)
+	ReportRestCABundle = Getenv(constants.ThundraLambdaReportRestCABundle)
+	ReportRestClientCert = Getenv(constants.ThundraLambdaReportRestClientCert)
+	ReportRestClientKey = Getenv(constants.ThundraLambdaReportRestClientKey)
 	MaskDynamoDBStatement = boolFromEnv(constants.ThundraMaskDynamoDBStatement, false)
 	MaskAthenaStatement = boolFromEnv(constants.ThundraMaskAthenaStatement, false)
 	MaskRDBStatement = boolFromEnv(constants.ThundraMaskRDBStatement, false)
@@ -89,13 +149,54 @@ func init() {
 	TraceCloudwatchlogRequestEnabled = boolFromEnv(constants.ThundraLambdaTraceCloudwatchlogRequestEnable, false)
 	DynamoDBTraceInjectionEnabled = boolFromEnv(constants.EnableDynamoDbTraceInjection, false)
 	LambdaTraceInjectionDisabled = boolFromEnv(constants.DisableLambdaTraceInjection, false)
+	SQSTraceInjectionDisabled = boolFromEnv(constants.DisableSQSTraceInjection, false)
+	SNSTraceInjectionDisabled = boolFromEnv(constants.DisableSNSTraceInjection, false)
 	ReportCloudwatchCompositeBatchSize = intFromEnv(constants.ThundraLambdaReportCloudwatchCompositeBatchSize,
 		constants.ThundraLambdaReportCloudwatchCompositeBatchSizeDefault)
 	ReportRestCompositeBatchSize = intFromEnv(constants.ThundraLambdaReportRestCompositeBatchSize,
 		constants.ThundraLambdaReportRestCompositeBatchSizeDefault)
+	ReportRestMaxBytes = intFromEnv(constants.ThundraLambdaReportRestMaxBytes,
+		constants.ThundraLambdaReportRestMaxBytesDefault)
+	ReportCloudwatchMaxBytes = intFromEnv(constants.ThundraLambdaReportCloudwatchMaxBytes,
+		constants.ThundraLambdaReportCloudwatchMaxBytesDefault)
 	ReportRestCompositeDataEnabled = boolFromEnv(constants.ThundraLambdaReportRestCompositeEnable, true)
 	ReportCloudwatchCompositeDataEnabled = boolFromEnv(constants.ThundraLambdaReportCloudwatchCompositeEnable, true)
 	ReportCloudwatchEnabled = boolFromEnv(constants.ThundraLambdaReportCloudwatchEnable, false)
+	ReportOTLPEnabled = boolFromEnv(constants.ThundraLambdaReportOTLPEnable, false)
+	ReportOTLPEndpoint = determineOTLPEndpoint()
+	ReportOTLPHeaders = parseHeaders(Getenv(constants.ThundraLambdaReportOTLPHeaders))
+	ReportZipkinEnabled = boolFromEnv(constants.ThundraLambdaReportZipkinEnable, false)
+	ReportZipkinURL = stringFromEnv(constants.ThundraLambdaReportZipkinURL, constants.DefaultZipkinURL)
+	XRayEnabled = boolFromEnv(constants.ThundraLambdaTraceXRayEnable, false)
+	XRayDaemonAddress = determineXRayDaemonAddress()
+	MetricEMFEnabled = boolFromEnv(constants.ThundraLambdaMetricCloudwatchEMFEnable, false)
+	MetricEMFNamespace = stringFromEnv(constants.ThundraLambdaMetricCloudwatchEMFNamespace, constants.DefaultEMFNamespace)
+	MetricEMFDimensions = listFromEnv(constants.ThundraLambdaMetricCloudwatchEMFDimensions)
+	ReportStatsDEnabled = boolFromEnv(constants.ThundraLambdaReportStatsDEnable, false)
+	ReportStatsDAddress = stringFromEnv(constants.ThundraLambdaReportStatsDAddress, constants.DefaultStatsDAddress)
+	ReportStatsDPrefix = stringFromEnv(constants.ThundraLambdaReportStatsDPrefix, constants.DefaultStatsDPrefix)
+	ReportStatsDFlavor = strings.ToLower(stringFromEnv(constants.ThundraLambdaReportStatsDFlavor, constants.DefaultStatsDFlavor))
+	ReportCompressionEnabled = boolFromEnv(constants.ThundraLambdaReportCompressionEnable, false)
+	ReportCompressionThreshold = intFromEnv(constants.ThundraLambdaReportCompressionThreshold,
+		constants.DefaultReportCompressionThreshold)
+	ReportRestRetryCount = intFromEnv(constants.ThundraLambdaReportRestRetryCount, constants.DefaultReportRestRetryCount)
+	ReportRestRetryBackoff = time.Duration(intFromEnv(constants.ThundraLambdaReportRestRetryBackoff,
+		constants.DefaultReportRestRetryBackoff)) * time.Millisecond
+	ReportSpoolDir = stringFromEnv(constants.ThundraLambdaReportSpoolDir, constants.DefaultReportSpoolDir)
+	ReportSpoolMaxSize = intFromEnv(constants.ThundraLambdaReportSpoolMaxSize, constants.DefaultReportSpoolMaxSize)
+	ExtensionEnabled = boolFromEnv(constants.ThundraLambdaExtensionEnable, false)
+	ReportRestTimeout = time.Duration(intFromEnv(constants.ThundraLambdaReportRestTimeout,
+		constants.DefaultReportRestTimeout)) * time.Millisecond
+	ReportRestConnectTimeout = time.Duration(intFromEnv(constants.ThundraLambdaReportRestConnectTimeout,
+		constants.DefaultReportRestConnectTimeout)) * time.Millisecond
+	ReportRestPrewarmEnabled = boolFromEnv(constants.ThundraLambdaReportRestPrewarmEnable, false)
+	ReportFileEnabled = boolFromEnv(constants.ThundraLambdaReportFileEnable, false)
+	ReportFileDir = stringFromEnv(constants.ThundraLambdaReportFileDir, constants.DefaultReportFileDir)
+	ReportFileMaxSize = intFromEnv(constants.ThundraLambdaReportFileMaxSize, constants.DefaultReportFileMaxSize)
+	ReportFileMaxInvocations = intFromEnv(constants.ThundraLambdaReportFileMaxInvocations,
+		constants.DefaultReportFileMaxInvocations)
+	ReportRestProtobufEnabled = boolFromEnv(constants.ThundraLambdaReportRestProtobufEnable, false)
+	SpanMaxLogs = intFromEnv(constants.ThundraLambdaSpanMaxLogs, constants.DefaultSpanMaxLogs)
 	MaskMongoDBCommand = boolFromEnv(constants.ThundraMaskMongoDBCommand, false)
 	SamplingCountFrequency = intFromEnv(constants.ThundraAgentMetricCountAwareSamplerCountFreq, -1)
 	SamplingTimeFrequency = intFromEnv(constants.ThundraAgentMetricTimeAwareSamplerTimeFreq, -1)
@@ -109,29 +210,53 @@ func init() {
 	HTTPIntegrationUrlPathDepth = intFromEnv(constants.ThundraAgentTraceIntegrationsHttpUrlDepth, 1)
 	EsIntegrationUrlPathDepth = intFromEnv(constants.ThundraAgentTraceIntegrationsEsUrlDepth, 1)
 	AwsLambdaFunctionMemorySize = intFromEnv(constants.AwsLambdaFunctionMemorySize, -1)
-	AwsLambdaRegion = os.Getenv(constants.AwsLambdaRegion)
+	AwsLambdaRegion = Getenv(constants.AwsLambdaRegion)
+	AwsLambdaRuntimeAPI = Getenv(constants.AwsLambdaRuntimeAPI)
 	TimeoutMargin = time.Duration(intFromEnv(constants.ThundraLambdaTimeoutMargin,
 		getDefaultTimeoutMargin())) * time.Millisecond
 
-	CollectorUrl = "https://" + getDefaultCollector() + "/v1"
+	CollectorUrl = stringFromEnv(constants.ThundraLambdaReportRestBaseURL, "https://"+getDefaultCollector()+"/v1")
 }
 
 func boolFromEnv(key string, defaultValue bool) bool {
-	env := os.Getenv(key)
+	env := Getenv(key)
 	value, err := strconv.ParseBool(env)
 	if err != nil {
 		if env != "" {
 			log.Printf("%v: %s is not a bool value", err, key)
 		}
-		return defaultValue
+		value = defaultValue
+	}
+	recordSetting(key, value)
+	return value
+}
+
+func stringFromEnv(key string, defaultValue string) string {
+	value := Getenv(key)
+	if value == "" {
+		value = defaultValue
 	}
+	recordSetting(key, value)
 	return value
 }
 
+// listFromEnv returns the non-empty items of the comma separated list in the given env variable
+func listFromEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	recordSetting(key, strings.Join(items, ","))
+	return items
+}
+
 func intFromEnv(key string, defaultValue int) int {
-	t := os.Getenv(key)
+	t := Getenv(key)
 	// environment variable is not set
 	if t == "" {
+		recordSetting(key, defaultValue)
 		return defaultValue
 	}
 
@@ -140,13 +265,14 @@ func intFromEnv(key string, defaultValue int) int {
 	// environment variable is not set in the correct format
 	if err != nil {
 		log.Printf("%v: %s should be set with an integer\n", err, key)
-		return defaultValue
+		i = defaultValue
 	}
+	recordSetting(key, i)
 	return i
 }
 
 func determineAPIKey() string {
-	apiKey := os.Getenv(constants.ThundraAPIKey)
+	apiKey := Getenv(constants.ThundraAPIKey)
 	if apiKey == "" {
 		log.Println("Error no APIKey in env variables")
 	}
@@ -154,7 +280,7 @@ func determineAPIKey() string {
 }
 
 func isThundraDebugEnabled() bool {
-	b, err := strconv.ParseBool(os.Getenv(constants.ThundraLambdaDebugEnable))
+	b, err := strconv.ParseBool(Getenv(constants.ThundraLambdaDebugEnable))
 	if err != nil {
 		return false
 	}
@@ -162,7 +288,7 @@ func isThundraDebugEnabled() bool {
 }
 
 func trustAllCertificates() bool {
-	b, err := strconv.ParseBool(os.Getenv(constants.ThundraTrustAllCertificates))
+	b, err := strconv.ParseBool(Getenv(constants.ThundraTrustAllCertificates))
 	if err != nil {
 		return false
 	}
@@ -170,10 +296,61 @@ func trustAllCertificates() bool {
 }
 
 func determineLogLevel() string {
-	level := os.Getenv(constants.ThundraLogLogLevel)
+	level := Getenv(constants.ThundraLogLogLevel)
 	return strings.ToUpper(level)
 }
 
+func determineTracePropagationFormat() string {
+	format := Getenv(constants.ThundraLambdaTracePropagationFormat)
+	if format == "" {
+		format = constants.DefaultTracePropagationFormat
+	}
+	format = strings.ToLower(format)
+	recordSetting(constants.ThundraLambdaTracePropagationFormat, format)
+	return format
+}
+
+func determineOTLPEndpoint() string {
+	endpoint := Getenv(constants.ThundraLambdaReportOTLPEndpoint)
+	if endpoint == "" {
+		endpoint = Getenv(constants.OTelExporterOTLPEndpoint)
+	}
+	if endpoint == "" {
+		endpoint = constants.DefaultOTLPEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	recordSetting(constants.ThundraLambdaReportOTLPEndpoint, endpoint)
+	return endpoint
+}
+
+// determineXRayDaemonAddress returns the UDP address of the X-Ray daemon. The address
+// can be given either as host:port or as "tcp:host:port udp:host:port".
+func determineXRayDaemonAddress() string {
+	address := Getenv(constants.AwsXRayDaemonAddress)
+	for _, part := range strings.Fields(address) {
+		if strings.HasPrefix(part, "udp:") {
+			return strings.TrimPrefix(part, "udp:")
+		}
+	}
+	if address == "" || strings.Contains(address, " ") || strings.HasPrefix(address, "tcp:") {
+		return constants.DefaultXRayDaemonAddress
+	}
+	return address
+}
+
+// parseHeaders parses headers given in the key1=value1,key2=value2 form
+func parseHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers
+}
+
 func getDefaultTimeoutMargin() int {
 	region := AwsLambdaRegion
 	memory := AwsLambdaFunctionMemorySize
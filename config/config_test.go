@@ -1,9 +1,11 @@
 package config
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 )
 
 func TestGetDefaultTimeoutMargin(t *testing.T) {
@@ -58,3 +60,25 @@ func TestGetDefaultCollector(t *testing.T) {
 	collector := getDefaultCollector()
 	assert.Equal(t, "collector.thundra.io", collector)
 }
+
+func TestDetermineTracePropagationFormat(t *testing.T) {
+	os.Unsetenv(constants.ThundraLambdaTracePropagationFormat)
+	assert.Equal(t, "w3c", determineTracePropagationFormat())
+
+	os.Setenv(constants.ThundraLambdaTracePropagationFormat, "B3Single")
+	defer os.Unsetenv(constants.ThundraLambdaTracePropagationFormat)
+	assert.Equal(t, "b3single", determineTracePropagationFormat())
+}
+
+func TestDetermineXRayDaemonAddress(t *testing.T) {
+	defer os.Unsetenv(constants.AwsXRayDaemonAddress)
+
+	os.Unsetenv(constants.AwsXRayDaemonAddress)
+	assert.Equal(t, "127.0.0.1:2000", determineXRayDaemonAddress())
+
+	os.Setenv(constants.AwsXRayDaemonAddress, "169.254.79.129:2000")
+	assert.Equal(t, "169.254.79.129:2000", determineXRayDaemonAddress())
+
+	os.Setenv(constants.AwsXRayDaemonAddress, "tcp:127.0.0.1:2001 udp:127.0.0.1:2002")
+	assert.Equal(t, "127.0.0.1:2002", determineXRayDaemonAddress())
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+const (
+	sourceDefault = "default"
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceRemote  = "remote"
+)
+
+// settingPrefix is the prefix of the names of the settings checked for typos
+const settingPrefix = "thundra_"
+
+// knownSettingPrefixes are the prefixes of the settings whose names are given by the user
+var knownSettingPrefixes = []string{constants.ApplicationTagPrefixProp, constants.ThundraLambdaSpanListener}
+
+// secretSettingNames are the parts of the names of the settings whose values are masked in the report
+var secretSettingNames = []string{"key", "cert", "bundle", "secret", "password", "token", "headers"}
+
+// knownSettings are the values of the settings read so far keyed by their env variable names.
+// effectiveSettings are the values parsed from them or the defaults used instead.
+var knownSettings = map[string]string{}
+var effectiveSettings = map[string]string{}
+var knownSettingsMutex sync.Mutex
+
+// recordSetting records the effective value of the setting with the given name
+func recordSetting(key string, value interface{}) {
+	knownSettingsMutex.Lock()
+	defer knownSettingsMutex.Unlock()
+	effectiveSettings[key] = fmt.Sprint(value)
+}
+
+// recordRawSetting records the value of the setting as it is read
+func recordRawSetting(key string, value string) {
+	knownSettingsMutex.Lock()
+	defer knownSettingsMutex.Unlock()
+	knownSettings[key] = value
+}
+
+// LogSettings logs the effective value of every known setting with where it is read from.
+// Secrets are masked. The settings starting with thundra_ which are not known are warned about
+// since they are most probably misspelled.
+func LogSettings() {
+	for _, line := range settingsReport() {
+		log.Println(line)
+	}
+}
+
+func settingsReport() []string {
+	knownSettingsMutex.Lock()
+	settings := map[string]string{}
+	for key, value := range knownSettings {
+		settings[key] = value
+	}
+	for key, value := range effectiveSettings {
+		settings[key] = value
+	}
+	knownSettingsMutex.Unlock()
+
+	var unknown []string
+	for _, key := range settingNames() {
+		if _, ok := settings[key]; ok {
+			continue
+		}
+		if hasKnownSettingPrefix(key) {
+			settings[key] = Getenv(key)
+		} else if strings.HasPrefix(strings.ToLower(key), settingPrefix) {
+			unknown = append(unknown, key)
+		}
+	}
+
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sort.Strings(unknown)
+
+	report := []string{"Thundra agent settings:"}
+	for _, key := range keys {
+		source := settingSource(key)
+		report = append(report, fmt.Sprintf("  %s=%s (source: %s, explicitly set: %t)",
+			key, maskSetting(key, settings[key]), source, source != sourceDefault))
+	}
+	for _, key := range unknown {
+		report = append(report, fmt.Sprintf("Unknown setting %s is ignored, check whether its name is misspelled", key))
+	}
+	return report
+}
+
+// settingNames returns the names of the settings given in the environment, the config file and the remote config
+func settingNames() []string {
+	names := map[string]bool{}
+	for _, env := range os.Environ() {
+		names[strings.SplitN(env, "=", 2)[0]] = true
+	}
+	for key := range fileValues {
+		names[key] = true
+	}
+	for key := range remoteValues {
+		names[key] = true
+	}
+	var result []string
+	for name := range names {
+		result = append(result, name)
+	}
+	return result
+}
+
+func hasKnownSettingPrefix(key string) bool {
+	for _, prefix := range knownSettingPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// settingSource returns where the value of the setting is read from in the order Getenv looks it up
+func settingSource(key string) string {
+	if _, ok := remoteValues[key]; ok {
+		return sourceRemote
+	}
+	if os.Getenv(key) != "" {
+		return sourceEnv
+	}
+	if _, ok := fileValues[key]; ok {
+		return sourceFile
+	}
+	return sourceDefault
+}
+
+func maskSetting(key string, value string) string {
+	if value == "" {
+		return value
+	}
+	name := strings.ToLower(key)
+	for _, secret := range secretSettingNames {
+		if strings.Contains(name, secret) {
+			// The end of long secrets is shown to tell them apart
+			if len(value) >= 12 {
+				return "****" + value[len(value)-4:]
+			}
+			return "****"
+		}
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+func TestSettingsReport(t *testing.T) {
+	path := writeConfigFile(t, "thundra-config.json", `{"thundra_agent_lambda_report_rest_composite_batchsize": 50}`)
+	defer os.RemoveAll(path)
+	env := map[string]string{
+		constants.ThundraLambdaConfigFile:                           path,
+		constants.ThundraAPIKey:                                     "0123456789abcdef",
+		constants.ThundraMaskHTTPBody:                               "true",
+		constants.ThundraLambdaTimeoutMargin:                        "not-a-number",
+		constants.ApplicationTagPrefixProp + "team":                 "payments",
+		"thundra_agent_lambda_trace_integrations_aws_http_body_msk": "true",
+	}
+	for key, value := range env {
+		os.Setenv(key, value)
+	}
+	defer func() {
+		for key := range env {
+			os.Unsetenv(key)
+		}
+		loadConfigFile()
+		load()
+	}()
+	loadConfigFile()
+	load()
+
+	report := settingsReport()
+
+	assert.Equal(t, "Thundra agent settings:", report[0])
+	assert.Contains(t, report, "  thundra_apiKey=****cdef (source: env, explicitly set: true)")
+	assert.Contains(t, report, "  thundra_agent_lambda_trace_integrations_aws_http_body_mask=true (source: env, explicitly set: true)")
+	assert.Contains(t, report, "  thundra_agent_lambda_report_rest_composite_batchsize=50 (source: file, explicitly set: true)")
+	assert.Contains(t, report, "  thundra_agent_lambda_trace_disable=false (source: default, explicitly set: false)")
+	assert.Contains(t, report, "  thundra_agent_lambda_application_tag_team=payments (source: env, explicitly set: true)")
+	// The default is used for the invalid value
+	assert.Contains(t, report, "  thundra_agent_lambda_timeout_margin="+
+		strconv.Itoa(int(TimeoutMargin/time.Millisecond))+" (source: env, explicitly set: true)")
+	assert.Contains(t, report, "Unknown setting thundra_agent_lambda_trace_integrations_aws_http_body_msk is ignored, "+
+		"check whether its name is misspelled")
+	assert.NotContains(t, report, "Unknown setting thundra_apiKey is ignored, check whether its name is misspelled")
+}
+
+func TestMaskSetting(t *testing.T) {
+	assert.Equal(t, "****", maskSetting(constants.ThundraAPIKey, "short"))
+	assert.Equal(t, "****cdef", maskSetting(constants.ThundraAPIKey, "0123456789abcdef"))
+	assert.Equal(t, "****", maskSetting(constants.ThundraLambdaReportOTLPHeaders, "k=secret"))
+	assert.Equal(t, "****", maskSetting(constants.ThundraLambdaReportRestClientKey, "key.pem"))
+	assert.Equal(t, "****.pem", maskSetting(constants.ThundraLambdaReportRestClientCert, "/opt/certs/client.pem"))
+	assert.Equal(t, "****.pem", maskSetting(constants.ThundraLambdaReportRestCABundle, "/opt/certs/ca-bundle.pem"))
+	assert.Equal(t, "", maskSetting(constants.ThundraAPIKey, ""))
+	assert.Equal(t, "true", maskSetting(constants.ThundraMaskHTTPBody, "true"))
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileExtensions are the extensions of the default config files in the order they are looked up
+var configFileExtensions = []string{".json", ".yaml", ".yml"}
+
+// fileValues are the settings read from the config file keyed by their env variable names
+var fileValues = map[string]string{}
+
+// Getenv returns the value of the setting with the given env variable name.
+// The remote config overrides env variables which override the values in the config file.
+func Getenv(key string) string {
+	value, ok := remoteValues[key]
+	if !ok {
+		if value = os.Getenv(key); value == "" {
+			value = fileValues[key]
+		}
+	}
+	recordRawSetting(key, value)
+	return value
+}
+
+// Environ returns the settings in the key=value form of os.Environ including the ones in the config file
+// which are not overridden by env variables and the ones in the remote config
+func Environ() []string {
+	var environ []string
+	for _, env := range os.Environ() {
+		if _, ok := remoteValues[strings.SplitN(env, "=", 2)[0]]; !ok {
+			environ = append(environ, env)
+		}
+	}
+	for key, value := range fileValues {
+		if _, ok := remoteValues[key]; !ok && os.Getenv(key) == "" {
+			environ = append(environ, key+"="+value)
+		}
+	}
+	for key, value := range remoteValues {
+		environ = append(environ, key+"="+value)
+	}
+	return environ
+}
+
+// loadConfigFile reads the settings in the file given by thundra_agent_lambda_config_file.
+// If it is not set, thundra-config.json, thundra-config.yaml or thundra-config.yml next to
+// the executable is read if it exists.
+func loadConfigFile() {
+	fileValues = map[string]string{}
+	path := os.Getenv(constants.ThundraLambdaConfigFile)
+	if path == "" {
+		if path = defaultConfigFile(); path == "" {
+			return
+		}
+	}
+	recordSetting(constants.ThundraLambdaConfigFile, path)
+	values, err := readConfigFile(path)
+	if err != nil {
+		log.Printf("Error while reading the config file %s: %v\n", path, err)
+		return
+	}
+	fileValues = values
+}
+
+func defaultConfigFile() string {
+	executable, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	for _, ext := range configFileExtensions {
+		path := filepath.Join(filepath.Dir(executable), constants.DefaultConfigFile+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// readConfigFile reads the JSON or YAML config file at the given path. YAML is expected if the
+// file has the .yaml or .yml extension. The settings are keyed by their env variable names.
+// Nested objects are flattened by joining their keys with underscores, so
+//
+//	thundra_agent_lambda_metric_sample_sampler: {timeAware: {timeFreq: 300000}}
+//
+// sets thundra_agent_lambda_metric_sample_sampler_timeAware_timeFreq. Lists are kept as JSON.
+func readConfigFile(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var content map[string]interface{}
+		if err := yaml.Unmarshal(b, &content); err != nil {
+			return nil, err
+		}
+		return flattenContent(content)
+	default:
+		return parseJSONConfig(b)
+	}
+}
+
+// parseJSONConfig returns the flattened settings of the JSON object in b
+func parseJSONConfig(b []byte) (map[string]string, error) {
+	var content map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&content); err != nil {
+		return nil, err
+	}
+	return flattenContent(content)
+}
+
+func flattenContent(content map[string]interface{}) (map[string]string, error) {
+	values := map[string]string{}
+	if err := flattenConfig("", content, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// jsonSettingPrefixes are the prefixes of the settings whose values are JSON, so their objects are not flattened
+var jsonSettingPrefixes = []string{constants.ThundraLambdaSpanListener}
+
+func flattenConfig(prefix string, content map[string]interface{}, values map[string]string) error {
+	for k, v := range content {
+		key := k
+		if prefix != "" {
+			key = prefix + "_" + k
+		}
+		switch v := v.(type) {
+		case nil:
+		case map[string]interface{}:
+			if isJSONSetting(key) {
+				if err := setJSONValue(key, v, values); err != nil {
+					return err
+				}
+			} else if err := flattenConfig(key, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			if err := setJSONValue(key, v, values); err != nil {
+				return err
+			}
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func setJSONValue(key string, v interface{}, values map[string]string) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	values[key] = string(b)
+	return nil
+}
+
+func isJSONSetting(key string) bool {
+	for _, prefix := range jsonSettingPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "thundra-config")
+	assert.Nil(t, err)
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+const testJSONConfig = `{
+	"thundra_apiKey": "file-api-key",
+	"thundra_agent_lambda_report_rest_composite_batchsize": 50,
+	"thundra_lambda_debug_enable": true,
+	"thundra_agent_lambda_metric_sample_sampler": {
+		"timeAware": {"timeFreq": 300000},
+		"countAware": {"countFreq": 10}
+	},
+	"thundra_agent_lambda_trace_span_listenerConfig": [
+		{"type": "TagInjectorSpanListener", "config": {"tags": {"env": "prod"}}}
+	]
+}`
+
+const testYAMLConfig = `
+thundra_apiKey: file-api-key
+thundra_agent_lambda_report_rest_composite_batchsize: 50
+thundra_lambda_debug_enable: true
+thundra_agent_lambda_metric_sample_sampler:
+  timeAware:
+    timeFreq: 300000
+  countAware:
+    countFreq: 10
+thundra_agent_lambda_trace_span_listenerConfig:
+  - type: TagInjectorSpanListener
+    config:
+      tags:
+        env: prod
+`
+
+func TestReadConfigFile(t *testing.T) {
+	for name, content := range map[string]string{"thundra-config.json": testJSONConfig, "thundra-config.yaml": testYAMLConfig} {
+		path := writeConfigFile(t, name, content)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		values, err := readConfigFile(path)
+		assert.Nil(t, err, name)
+		assert.Equal(t, map[string]string{
+			"thundra_apiKey": "file-api-key",
+			"thundra_agent_lambda_report_rest_composite_batchsize":            "50",
+			"thundra_lambda_debug_enable":                                     "true",
+			"thundra_agent_lambda_metric_sample_sampler_timeAware_timeFreq":   "300000",
+			"thundra_agent_lambda_metric_sample_sampler_countAware_countFreq": "10",
+			"thundra_agent_lambda_trace_span_listenerConfig":                  `[{"config":{"tags":{"env":"prod"}},"type":"TagInjectorSpanListener"}]`,
+		}, values, name)
+	}
+}
+
+func TestReadConfigFileSingleSpanListener(t *testing.T) {
+	for name, content := range map[string]string{
+		"thundra-config.json": `{"thundra_agent_lambda_trace_span_listenerConfig": ` +
+			`{"type": "TagInjectorSpanListener", "config": {"tags": {"env": "prod"}}}}`,
+		"thundra-config.yaml": `
+thundra_agent_lambda_trace_span_listenerConfig:
+  type: TagInjectorSpanListener
+  config:
+    tags:
+      env: prod
+`,
+	} {
+		path := writeConfigFile(t, name, content)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		values, err := readConfigFile(path)
+		assert.Nil(t, err, name)
+		assert.Equal(t, map[string]string{
+			"thundra_agent_lambda_trace_span_listenerConfig": `{"config":{"tags":{"env":"prod"}},"type":"TagInjectorSpanListener"}`,
+		}, values, name)
+	}
+}
+
+func TestReadConfigFileInvalid(t *testing.T) {
+	path := writeConfigFile(t, "thundra-config.json", "{")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err := readConfigFile(path)
+	assert.NotNil(t, err)
+}
+
+func TestEnvOverridesConfigFile(t *testing.T) {
+	path := writeConfigFile(t, "thundra-config.json", testJSONConfig)
+	defer os.RemoveAll(filepath.Dir(path))
+	os.Setenv(constants.ThundraLambdaConfigFile, path)
+	os.Setenv(constants.ThundraLambdaReportRestCompositeBatchSize, "20")
+	defer func() {
+		os.Unsetenv(constants.ThundraLambdaConfigFile)
+		os.Unsetenv(constants.ThundraLambdaReportRestCompositeBatchSize)
+		loadConfigFile()
+	}()
+
+	loadConfigFile()
+
+	assert.Equal(t, "file-api-key", Getenv(constants.ThundraAPIKey))
+	assert.Equal(t, 20, intFromEnv(constants.ThundraLambdaReportRestCompositeBatchSize, 100))
+	assert.Equal(t, 300000, intFromEnv(constants.ThundraAgentMetricTimeAwareSamplerTimeFreq, -1))
+	assert.True(t, boolFromEnv(constants.ThundraLambdaDebugEnable, false))
+
+	environ := Environ()
+	assert.Contains(t, environ, constants.ThundraLambdaReportRestCompositeBatchSize+"=20")
+	assert.NotContains(t, environ, constants.ThundraLambdaReportRestCompositeBatchSize+"=50")
+	assert.Contains(t, environ, constants.ThundraAPIKey+"=file-api-key")
+}
+
+func TestMissingConfigFile(t *testing.T) {
+	os.Setenv(constants.ThundraLambdaConfigFile, "/nonexistent/thundra-config.json")
+	defer func() {
+		os.Unsetenv(constants.ThundraLambdaConfigFile)
+		loadConfigFile()
+	}()
+
+	loadConfigFile()
+
+	assert.Empty(t, fileValues)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+// maxRemoteConfigSize is the maximum number of bytes read from the remote config endpoint
+const maxRemoteConfigSize = 1024 * 1024
+
+// remoteValues are the settings fetched from the remote config endpoint keyed by their env variable names
+var remoteValues = map[string]string{}
+
+var reloadListeners []func()
+var lastRemoteConfigPoll time.Time
+var remoteConfigMutex sync.Mutex
+
+// OnReload registers a function which is called after the settings are reloaded because the remote config
+// has changed. The packages building state from the settings such as span listeners and samplers rebuild it there.
+func OnReload(listener func()) {
+	reloadListeners = append(reloadListeners, listener)
+}
+
+// PollRemoteConfig fetches the settings from the endpoint given by thundra_agent_lambda_remote_config_url such as
+// the AWS AppConfig extension at http://localhost:2772/applications/<app>/environments/<env>/configurations/<config>
+// or the Parameters and Secrets extension at http://localhost:2773/systemsmanager/parameters/get?name=<name>.
+// The endpoint is polled at most once in thundra_agent_lambda_remote_config_poll_interval seconds and the settings
+// are reloaded if the fetched ones have changed. Errors are logged and the current settings are kept.
+func PollRemoteConfig() {
+	url := Getenv(constants.ThundraLambdaRemoteConfigURL)
+	interval := time.Duration(intFromEnv(constants.ThundraLambdaRemoteConfigPollInterval,
+		constants.DefaultRemoteConfigPollInterval)) * time.Second
+	timeout := time.Duration(intFromEnv(constants.ThundraLambdaRemoteConfigTimeout,
+		constants.DefaultRemoteConfigTimeout)) * time.Millisecond
+	if url == "" {
+		return
+	}
+	remoteConfigMutex.Lock()
+	defer remoteConfigMutex.Unlock()
+
+	if !lastRemoteConfigPoll.IsZero() && time.Since(lastRemoteConfigPoll) < interval {
+		return
+	}
+	lastRemoteConfigPoll = time.Now()
+
+	values, err := fetchRemoteConfig(url, timeout)
+	if err != nil {
+		log.Println("Error while fetching the remote config:", err)
+		return
+	}
+	if reflect.DeepEqual(values, remoteValues) {
+		return
+	}
+	remoteValues = values
+	reload()
+}
+
+// reload sets the settings again and notifies the reload listeners
+func reload() {
+	load()
+	for _, listener := range reloadListeners {
+		callReloadListener(listener)
+	}
+}
+
+func callReloadListener(listener func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("Error while reloading the settings:", r)
+		}
+	}()
+	listener()
+}
+
+func fetchRemoteConfig(url string, timeout time.Duration) (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Required by the Parameters and Secrets extension
+	if token := os.Getenv(constants.AwsSessionToken); token != "" {
+		req.Header.Set("X-Aws-Parameters-Secrets-Token", token)
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize))
+	if err != nil {
+		return nil, err
+	}
+	return parseRemoteConfig(b)
+}
+
+// parseRemoteConfig returns the settings in the fetched JSON object. The responses of the Parameters and
+// Secrets extension are unwrapped, so the object is expected in the value of the parameter or the secret.
+func parseRemoteConfig(b []byte) (map[string]string, error) {
+	var response struct {
+		Parameter *struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(b, &response); err == nil {
+		if response.Parameter != nil {
+			b = []byte(response.Parameter.Value)
+		} else if response.SecretString != nil {
+			b = []byte(*response.SecretString)
+		}
+	}
+	return parseJSONConfig(b)
+}
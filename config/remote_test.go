@@ -0,0 +1,130 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+// startRemoteConfigServer serves the response returned by body and sets it as the remote config endpoint
+func startRemoteConfigServer(t *testing.T, body func() string) (*int32, func()) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(body()))
+	}))
+	os.Setenv(constants.ThundraLambdaRemoteConfigURL, server.URL)
+	lastRemoteConfigPoll = time.Time{}
+	listeners := reloadListeners
+	return &requests, func() {
+		server.Close()
+		os.Unsetenv(constants.ThundraLambdaRemoteConfigURL)
+		os.Unsetenv(constants.ThundraLambdaRemoteConfigPollInterval)
+		lastRemoteConfigPoll = time.Time{}
+		remoteValues = map[string]string{}
+		reloadListeners = listeners
+		load()
+	}
+}
+
+func TestPollRemoteConfig(t *testing.T) {
+	os.Setenv(constants.ThundraMaskHTTPBody, "false")
+	defer os.Unsetenv(constants.ThundraMaskHTTPBody)
+	_, stop := startRemoteConfigServer(t, func() string {
+		return `{"thundra_agent_lambda_trace_integrations_aws_http_body_mask": true,
+			"thundra_agent_lambda_metric_sample_sampler": {"countAware": {"countFreq": 10}}}`
+	})
+	defer stop()
+
+	var reloads int
+	OnReload(func() { reloads++ })
+	PollRemoteConfig()
+
+	assert.True(t, MaskHTTPBody)
+	assert.Equal(t, 10, SamplingCountFrequency)
+	assert.Equal(t, 1, reloads)
+	assert.Contains(t, Environ(), constants.ThundraMaskHTTPBody+"=true")
+	assert.NotContains(t, Environ(), constants.ThundraMaskHTTPBody+"=false")
+}
+
+func TestPollRemoteConfigInterval(t *testing.T) {
+	value := "true"
+	requests, stop := startRemoteConfigServer(t, func() string {
+		return `{"thundra_agent_lambda_trace_integrations_aws_http_body_mask": ` + value + `}`
+	})
+	defer stop()
+
+	var reloads int
+	OnReload(func() { reloads++ })
+	PollRemoteConfig()
+	value = "false"
+	PollRemoteConfig()
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	assert.True(t, MaskHTTPBody)
+
+	os.Setenv(constants.ThundraLambdaRemoteConfigPollInterval, "0")
+	PollRemoteConfig()
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+	assert.False(t, MaskHTTPBody)
+
+	// The settings are not reloaded if the remote config has not changed
+	PollRemoteConfig()
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+	assert.Equal(t, 2, reloads)
+}
+
+func TestPollRemoteConfigParameter(t *testing.T) {
+	os.Setenv(constants.AwsSessionToken, "session-token")
+	defer os.Unsetenv(constants.AwsSessionToken)
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Aws-Parameters-Secrets-Token")
+		w.Write([]byte(`{"Parameter": {"Name": "thundra", "Type": "String",
+			"Value": "{\"thundra_agent_lambda_report_rest_composite_batchsize\": 25}"}}`))
+	}))
+	defer server.Close()
+
+	values, err := fetchRemoteConfig(server.URL, time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, "session-token", token)
+	assert.Equal(t, map[string]string{constants.ThundraLambdaReportRestCompositeBatchSize: "25"}, values)
+}
+
+func TestParseRemoteConfigSecret(t *testing.T) {
+	values, err := parseRemoteConfig([]byte(`{"Name": "thundra", "SecretString": "{\"thundra_apiKey\": \"secret-key\"}"}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{constants.ThundraAPIKey: "secret-key"}, values)
+}
+
+func TestPollRemoteConfigInvalid(t *testing.T) {
+	body := `{"thundra_agent_lambda_trace_integrations_aws_http_body_mask": true}`
+	_, stop := startRemoteConfigServer(t, func() string { return body })
+	defer stop()
+	os.Setenv(constants.ThundraLambdaRemoteConfigPollInterval, "0")
+
+	OnReload(func() { panic("listener failed") })
+	PollRemoteConfig()
+	assert.True(t, MaskHTTPBody)
+
+	// The previous settings are kept
+	body = `{"thundra_agent_lambda_trace_integrations_aws_http_body_mask": `
+	PollRemoteConfig()
+	assert.True(t, MaskHTTPBody)
+}
+
+func TestPollRemoteConfigUnreachable(t *testing.T) {
+	_, stop := startRemoteConfigServer(t, func() string { return "{}" })
+	defer stop()
+	os.Setenv(constants.ThundraLambdaRemoteConfigURL, "http://127.0.0.1:1")
+
+	PollRemoteConfig()
+
+	assert.Empty(t, remoteValues)
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// Settings holds the values of all settings. Agents created with options keep their own Settings
+// which are passed to the plugins and the integrations with the context of their invocations.
+type Settings struct {
+	ThundraDisabled                      bool
+	TraceDisabled                        bool
+	MetricDisabled                       bool
+	AwsIntegrationDisabled               bool
+	LogDisabled                          bool
+	LogLevel                             string
+	TraceRequestDisabled                 bool
+	TraceResponseDisabled                bool
+	TimeoutMargin                        time.Duration
+	WarmupEnabled                        bool
+	DebugEnabled                         bool
+	Http4xxErrorDisabled                 bool
+	Http5xxErrorDisabled                 bool
+	APIKey                               string
+	TrustAllCertificates                 bool
+	ReportRestCABundle                   string
+	ReportRestClientCert                 string
+	ReportRestClientKey                  string
+	MaskDynamoDBStatement                bool
+	DynamoDBTraceInjectionEnabled        bool
+	LambdaTraceInjectionDisabled         bool
+	SQSTraceInjectionDisabled            bool
+	SNSTraceInjectionDisabled            bool
+	MaskRDBStatement                     bool
+	MaskEsBody                           bool
+	MaskRedisCommand                     bool
+	MaskMongoDBCommand                   bool
+	MaskSNSMessage                       bool
+	MaskSQSMessage                       bool
+	MaskLambdaPayload                    bool
+	MaskHTTPBody                         bool
+	MaskAthenaStatement                  bool
+	SAMLocalDebugging                    bool
+	TracePropagationFormat               string
+	MaskSESMail                          bool
+	MaskSESDestination                   bool
+	TraceKinesisRequestEnabled           bool
+	TraceFirehoseRequestEnabled          bool
+	TraceCloudwatchlogRequestEnabled     bool
+	ReportRestCompositeBatchSize         int
+	ReportCloudwatchCompositeBatchSize   int
+	ReportRestMaxBytes                   int
+	ReportCloudwatchMaxBytes             int
+	ReportRestCompositeDataEnabled       bool
+	ReportCloudwatchCompositeDataEnabled bool
+	ReportCloudwatchEnabled              bool
+	ReportOTLPEnabled                    bool
+	ReportOTLPEndpoint                   string
+	ReportOTLPHeaders                    map[string]string
+	ReportZipkinEnabled                  bool
+	ReportZipkinURL                      string
+	XRayEnabled                          bool
+	XRayDaemonAddress                    string
+	MetricEMFEnabled                     bool
+	MetricEMFNamespace                   string
+	MetricEMFDimensions                  []string
+	ReportStatsDEnabled                  bool
+	ReportStatsDAddress                  string
+	ReportStatsDPrefix                   string
+	ReportStatsDFlavor                   string
+	ReportCompressionEnabled             bool
+	ReportCompressionThreshold           int
+	ReportRestRetryCount                 int
+	ReportRestRetryBackoff               time.Duration
+	ReportSpoolDir                       string
+	ReportSpoolMaxSize                   int
+	ExtensionEnabled                     bool
+	ReportRestTimeout                    time.Duration
+	ReportRestConnectTimeout             time.Duration
+	ReportRestPrewarmEnabled             bool
+	ReportFileEnabled                    bool
+	ReportFileDir                        string
+	ReportFileMaxSize                    int
+	ReportFileMaxInvocations             int
+	ReportRestProtobufEnabled            bool
+	SpanMaxLogs                          int
+	SamplingCountFrequency               int
+	SamplingTimeFrequency                int
+	HTTPIntegrationUrlPathDepth          int
+	EsIntegrationUrlPathDepth            int
+	AwsLambdaFunctionMemorySize          int
+	AwsLambdaRegion                      string
+	AwsLambdaRuntimeAPI                  string
+	CollectorUrl                         string
+}
+
+// CurrentSettings returns the current values of the settings
+func CurrentSettings() Settings {
+	return Settings{
+		ThundraDisabled:                      ThundraDisabled,
+		TraceDisabled:                        TraceDisabled,
+		MetricDisabled:                       MetricDisabled,
+		AwsIntegrationDisabled:               AwsIntegrationDisabled,
+		LogDisabled:                          LogDisabled,
+		LogLevel:                             LogLevel,
+		TraceRequestDisabled:                 TraceRequestDisabled,
+		TraceResponseDisabled:                TraceResponseDisabled,
+		TimeoutMargin:                        TimeoutMargin,
+		WarmupEnabled:                        WarmupEnabled,
+		DebugEnabled:                         DebugEnabled,
+		Http4xxErrorDisabled:                 Http4xxErrorDisabled,
+		Http5xxErrorDisabled:                 Http5xxErrorDisabled,
+		APIKey:                               APIKey,
+		TrustAllCertificates:                 TrustAllCertificates,
+		ReportRestCABundle:                   ReportRestCABundle,
+		ReportRestClientCert:                 ReportRestClientCert,
+		ReportRestClientKey:                  ReportRestClientKey,
+		MaskDynamoDBStatement:                MaskDynamoDBStatement,
+		DynamoDBTraceInjectionEnabled:        DynamoDBTraceInjectionEnabled,
+		LambdaTraceInjectionDisabled:         LambdaTraceInjectionDisabled,
+		SQSTraceInjectionDisabled:            SQSTraceInjectionDisabled,
+		SNSTraceInjectionDisabled:            SNSTraceInjectionDisabled,
+		MaskRDBStatement:                     MaskRDBStatement,
+		MaskEsBody:                           MaskEsBody,
+		MaskRedisCommand:                     MaskRedisCommand,
+		MaskMongoDBCommand:                   MaskMongoDBCommand,
+		MaskSNSMessage:                       MaskSNSMessage,
+		MaskSQSMessage:                       MaskSQSMessage,
+		MaskLambdaPayload:                    MaskLambdaPayload,
+		MaskHTTPBody:                         MaskHTTPBody,
+		MaskAthenaStatement:                  MaskAthenaStatement,
+		SAMLocalDebugging:                    SAMLocalDebugging,
+		TracePropagationFormat:               TracePropagationFormat,
+		MaskSESMail:                          MaskSESMail,
+		MaskSESDestination:                   MaskSESDestination,
+		TraceKinesisRequestEnabled:           TraceKinesisRequestEnabled,
+		TraceFirehoseRequestEnabled:          TraceFirehoseRequestEnabled,
+		TraceCloudwatchlogRequestEnabled:     TraceCloudwatchlogRequestEnabled,
+		ReportRestCompositeBatchSize:         ReportRestCompositeBatchSize,
+		ReportCloudwatchCompositeBatchSize:   ReportCloudwatchCompositeBatchSize,
+		ReportRestMaxBytes:                   ReportRestMaxBytes,
+		ReportCloudwatchMaxBytes:             ReportCloudwatchMaxBytes,
+		ReportRestCompositeDataEnabled:       ReportRestCompositeDataEnabled,
+		ReportCloudwatchCompositeDataEnabled: ReportCloudwatchCompositeDataEnabled,
+		ReportCloudwatchEnabled:              ReportCloudwatchEnabled,
+		ReportOTLPEnabled:                    ReportOTLPEnabled,
+		ReportOTLPEndpoint:                   ReportOTLPEndpoint,
+		ReportOTLPHeaders:                    ReportOTLPHeaders,
+		ReportZipkinEnabled:                  ReportZipkinEnabled,
+		ReportZipkinURL:                      ReportZipkinURL,
+		XRayEnabled:                          XRayEnabled,
+		XRayDaemonAddress:                    XRayDaemonAddress,
+		MetricEMFEnabled:                     MetricEMFEnabled,
+		MetricEMFNamespace:                   MetricEMFNamespace,
+		MetricEMFDimensions:                  MetricEMFDimensions,
+		ReportStatsDEnabled:                  ReportStatsDEnabled,
+		ReportStatsDAddress:                  ReportStatsDAddress,
+		ReportStatsDPrefix:                   ReportStatsDPrefix,
+		ReportStatsDFlavor:                   ReportStatsDFlavor,
+		ReportCompressionEnabled:             ReportCompressionEnabled,
+		ReportCompressionThreshold:           ReportCompressionThreshold,
+		ReportRestRetryCount:                 ReportRestRetryCount,
+		ReportRestRetryBackoff:               ReportRestRetryBackoff,
+		ReportSpoolDir:                       ReportSpoolDir,
+		ReportSpoolMaxSize:                   ReportSpoolMaxSize,
+		ExtensionEnabled:                     ExtensionEnabled,
+		ReportRestTimeout:                    ReportRestTimeout,
+		ReportRestConnectTimeout:             ReportRestConnectTimeout,
+		ReportRestPrewarmEnabled:             ReportRestPrewarmEnabled,
+		ReportFileEnabled:                    ReportFileEnabled,
+		ReportFileDir:                        ReportFileDir,
+		ReportFileMaxSize:                    ReportFileMaxSize,
+		ReportFileMaxInvocations:             ReportFileMaxInvocations,
+		ReportRestProtobufEnabled:            ReportRestProtobufEnabled,
+		SpanMaxLogs:                          SpanMaxLogs,
+		SamplingCountFrequency:               SamplingCountFrequency,
+		SamplingTimeFrequency:                SamplingTimeFrequency,
+		HTTPIntegrationUrlPathDepth:          HTTPIntegrationUrlPathDepth,
+		EsIntegrationUrlPathDepth:            EsIntegrationUrlPathDepth,
+		AwsLambdaFunctionMemorySize:          AwsLambdaFunctionMemorySize,
+		AwsLambdaRegion:                      AwsLambdaRegion,
+		AwsLambdaRuntimeAPI:                  AwsLambdaRuntimeAPI,
+		CollectorUrl:                         CollectorUrl,
+	}
+}
+
+type settingsKey struct{}
+
+// ContextWithSettings returns a copy of ctx carrying the settings of the agent running the invocation
+func ContextWithSettings(ctx context.Context, s *Settings) context.Context {
+	return context.WithValue(ctx, settingsKey{}, s)
+}
+
+// SettingsFromContext returns the settings carried by ctx, or the current settings if ctx has none.
+// The returned settings must not be changed.
+func SettingsFromContext(ctx context.Context) *Settings {
+	if s, ok := ctx.Value(settingsKey{}).(*Settings); ok && s != nil {
+		return s
+	}
+	s := CurrentSettings()
+	return &s
+}
@@ -24,6 +24,9 @@ var SpanTags = map[string]string{
 	"TRIGGER_OPERATION_NAMES": "trigger.operationNames",
 	"TOPOLOGY_VERTEX":         "topology.vertex",
 	"TRACE_LINKS":             "trace.links",
+	"DEPTH":                   "span.depth",
+	"CHILDREN_COUNT":          "span.children_count",
+	"DROPPED_LOGS":            "span.dropped_logs",
 }
 
 var DBTags = map[string]string{
@@ -2,6 +2,7 @@ package constants
 
 const ApplicationPlatform = "AWS Lambda"
 const AwsDefaultRegion = "AWS_DEFAULT_REGION"
+const AwsLambdaRuntimeAPI = "AWS_LAMBDA_RUNTIME_API"
 const ThundraLambdaDebugEnable = "thundra_lambda_debug_enable"
 const AwsLambdaInvocationRequestId = "aws.lambda.invocation.request_id"
 const AwsLambdaInvocationRequest = "aws.lambda.invocation.request"
@@ -14,6 +15,17 @@ const AwsLambdaLogGroupName = "aws.lambda.log_group_name"
 const AwsLambdaLogStreamName = "aws.lambda.log_stream_name"
 const AwsLambdaMemoryLimit = "aws.lambda.memory_limit"
 const AwsLambdaMemoryUsage = "aws.lambda.invocation.memory_usage"
+const ThundraAgentReportDroppedBatches = "thundra.agent.report.dropped_batches"
+const ThundraAgentTruncatedTags = "thundra.agent.truncated_tags"
+const ThundraAgentReportLatency = "thundra.agent.report.latency"
+const ThundraAgentReportCollectedMessages = "thundra.agent.report.collected_messages"
+const ThundraAgentReportDroppedMessages = "thundra.agent.report.dropped_messages"
+const ThundraAgentReportSentBatches = "thundra.agent.report.sent_batches"
+const ThundraAgentReportRetries = "thundra.agent.report.retries"
+const ThundraAgentReportFailedRequests = "thundra.agent.report.failed_requests"
+const ThundraAgentReportSerializationErrors = "thundra.agent.report.serialization_errors"
+const ThundraAgentReportStatusClasses = "thundra.agent.report.status_classes"
+const ThundraAgentReportSendLatency = "thundra.agent.report.send_latency"
 const AwsLambdaName = "aws.lambda.name"
 const AwsRegion = "aws.region"
 const AwsError = "error"
@@ -28,11 +40,31 @@ const AwsLambdaTriggerDomainName = "x-thundra-trigger-domain-name"
 const AwsLambdaTriggerClassName = "x-thundra-trigger-class-name"
 const AwsLambdaTriggerResourceName = "x-thundra-resource-name"
 
+const ThundraTraceIDHeader = "x-thundra-trace-id"
+const ThundraTransactionIDHeader = "x-thundra-transaction-id"
+const ThundraSpanIDHeader = "x-thundra-span-id"
+const ThundraBaggagePrefix = "x-thundra-baggage-"
+
+const W3CTraceParentHeader = "traceparent"
+const W3CTraceStateHeader = "tracestate"
+const W3CTraceStateThundraKey = "thundra"
+const W3CTraceStateThundraSampledKey = "thundra-sampled"
+
+const B3TraceIDHeader = "x-b3-traceid"
+const B3SpanIDHeader = "x-b3-spanid"
+const B3ParentSpanIDHeader = "x-b3-parentspanid"
+const B3SampledHeader = "x-b3-sampled"
+const B3FlagsHeader = "x-b3-flags"
+const B3SingleHeader = "b3"
+
 const AwsLambdaFunctionMemorySize = "AWS_LAMBDA_FUNCTION_MEMORY_SIZE"
 const AwsLambdaRegion = "AWS_REGION"
+const AwsSessionToken = "AWS_SESSION_TOKEN"
 const AwsSAMLocal = "AWS_SAM_LOCAL"
 
 const AwsXRayTraceHeader = "_X_AMZN_TRACE_ID"
 const AwsXRayTraceContextKey = "x-amzn-trace-id"
 const AwsXRaySegmentID = "aws.xray.segment.id"
 const AwsXRayTraceID = "aws.xray.trace.id"
+const AwsXRayDaemonAddress = "AWS_XRAY_DAEMON_ADDRESS"
+const DefaultXRayDaemonAddress = "127.0.0.1:2000"
@@ -7,6 +7,15 @@ const DefaultProfile = "default"
 const DefaultClassName = "Method"
 const DefaultDomainName = ""
 
+const ThundraLambdaConfigFile = "thundra_agent_lambda_config_file"
+const DefaultConfigFile = "thundra-config"
+
+const ThundraLambdaRemoteConfigURL = "thundra_agent_lambda_remote_config_url"
+const ThundraLambdaRemoteConfigPollInterval = "thundra_agent_lambda_remote_config_poll_interval"
+const ThundraLambdaRemoteConfigTimeout = "thundra_agent_lambda_remote_config_timeout"
+const DefaultRemoteConfigPollInterval = 60
+const DefaultRemoteConfigTimeout = 1000
+
 const ThundraLambdaDisable = "thundra_agent_lambda_disable"
 const ThundraDisableTrace = "thundra_agent_lambda_trace_disable"
 const ThundraDisableMetric = "thundra_agent_lambda_metric_disable"
@@ -32,6 +41,56 @@ const ThundraLambdaReportRestBaseURL = "thundra_agent_lambda_report_rest_baseUrl
 const ThundraLambdaWarmupWarmupAware = "thundra_agent_lambda_warmup_warmupAware"
 const ThundraLambdaTimeoutMargin = "thundra_agent_lambda_timeout_margin"
 const ThundraTrustAllCertificates = "thundra_agent_lambda_report_rest_trustAllCertificates"
+const ThundraLambdaReportRestCABundle = "thundra_agent_lambda_report_rest_ca_bundle"
+const ThundraLambdaReportRestClientCert = "thundra_agent_lambda_report_rest_client_cert"
+const ThundraLambdaReportRestClientKey = "thundra_agent_lambda_report_rest_client_key"
+const ThundraLambdaReportOTLPEnable = "thundra_agent_lambda_report_otlp_enable"
+const ThundraLambdaReportOTLPEndpoint = "thundra_agent_lambda_report_otlp_endpoint"
+const ThundraLambdaReportOTLPHeaders = "thundra_agent_lambda_report_otlp_headers"
+const OTelExporterOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+const DefaultOTLPEndpoint = "http://localhost:4318"
+const OTLPTracesPath = "/v1/traces"
+const OTLPMetricsPath = "/v1/metrics"
+const ThundraLambdaReportZipkinEnable = "thundra_agent_lambda_report_zipkin_enable"
+const ThundraLambdaReportZipkinURL = "thundra_agent_lambda_report_zipkin_url"
+const DefaultZipkinURL = "http://localhost:9411/api/v2/spans"
+const ThundraLambdaTraceXRayEnable = "thundra_agent_lambda_trace_xray_enable"
+const ThundraLambdaMetricCloudwatchEMFEnable = "thundra_agent_lambda_metric_cloudwatch_emf_enable"
+const ThundraLambdaMetricCloudwatchEMFNamespace = "thundra_agent_lambda_metric_cloudwatch_emf_namespace"
+const ThundraLambdaMetricCloudwatchEMFDimensions = "thundra_agent_lambda_metric_cloudwatch_emf_dimensions"
+const DefaultEMFNamespace = "Thundra/Lambda"
+const ThundraLambdaReportStatsDEnable = "thundra_agent_lambda_report_statsd_enable"
+const ThundraLambdaReportStatsDAddress = "thundra_agent_lambda_report_statsd_address"
+const ThundraLambdaReportStatsDPrefix = "thundra_agent_lambda_report_statsd_prefix"
+const ThundraLambdaReportStatsDFlavor = "thundra_agent_lambda_report_statsd_flavor"
+const DefaultStatsDAddress = "127.0.0.1:8125"
+const DefaultStatsDPrefix = "thundra."
+const DefaultStatsDFlavor = "dogstatsd"
+const ThundraLambdaReportCompressionEnable = "thundra_agent_lambda_report_compression_enable"
+const ThundraLambdaReportCompressionThreshold = "thundra_agent_lambda_report_compression_threshold"
+const DefaultReportCompressionThreshold = 1024
+const ThundraLambdaReportRestRetryCount = "thundra_agent_lambda_report_rest_retry_count"
+const ThundraLambdaReportRestRetryBackoff = "thundra_agent_lambda_report_rest_retry_backoff"
+const ThundraLambdaReportSpoolDir = "thundra_agent_lambda_report_spool_dir"
+const ThundraLambdaReportSpoolMaxSize = "thundra_agent_lambda_report_spool_maxsize"
+const DefaultReportRestRetryCount = 3
+const DefaultReportRestRetryBackoff = 100
+const DefaultReportSpoolDir = "/tmp/thundra-spool"
+const DefaultReportSpoolMaxSize = 10 * 1024 * 1024
+const ThundraLambdaExtensionEnable = "thundra_agent_lambda_extension_enable"
+const ThundraLambdaReportRestTimeout = "thundra_agent_lambda_report_rest_timeout"
+const ThundraLambdaReportRestConnectTimeout = "thundra_agent_lambda_report_rest_connect_timeout"
+const ThundraLambdaReportRestPrewarmEnable = "thundra_agent_lambda_report_rest_prewarm_enable"
+const DefaultReportRestTimeout = 5000
+const DefaultReportRestConnectTimeout = 1000
+const ThundraLambdaReportFileEnable = "thundra_agent_lambda_report_file_enable"
+const ThundraLambdaReportFileDir = "thundra_agent_lambda_report_file_dir"
+const ThundraLambdaReportFileMaxSize = "thundra_agent_lambda_report_file_maxsize"
+const ThundraLambdaReportFileMaxInvocations = "thundra_agent_lambda_report_file_maxinvocations"
+const DefaultReportFileDir = "/tmp/thundra"
+const DefaultReportFileMaxSize = 10 * 1024 * 1024
+const DefaultReportFileMaxInvocations = 100
+const ThundraLambdaReportRestProtobufEnable = "thundra_agent_lambda_report_rest_protobuf_enable"
 
 const ApplicationIDProp = "thundra_agent_lambda_application_id"
 const ApplicationDomainProp = "thundra_agent_lambda_application_domainName"
@@ -43,6 +102,10 @@ const ApplicationTagPrefixProp = "thundra_agent_lambda_application_tag_"
 
 const ThundraLambdaSpanListener = "thundra_agent_lambda_trace_span_listenerConfig"
 const ThundraLambdaSpanListenerInfoTag = "thundra.span_listener.info"
+const ThundraLambdaSpanMaxLogs = "thundra_agent_lambda_trace_span_max_logs"
+const DefaultSpanMaxLogs = 100
+const ThundraLambdaTracePropagationFormat = "thundra_agent_lambda_trace_propagation_format"
+const DefaultTracePropagationFormat = "w3c"
 
 const ThundraMaskDynamoDBStatement = "thundra_agent_lambda_trace_integrations_aws_dynamodb_statement_mask"
 const ThundraMaskRDBStatement = "thundra_agent_lambda_trace_integrations_rdb_statement_mask"
@@ -57,6 +120,8 @@ const ThundraMaskAthenaStatement = "thundra_agent_lambda_trace_integrations_aws_
 
 const EnableDynamoDbTraceInjection = "thundra_agent_lambda_trace_integrations_dynamodb_trace_injection_enable"
 const DisableLambdaTraceInjection = "thundra_agent_lambda_trace_integrations_aws_lambda_traceInjection_disable"
+const DisableSQSTraceInjection = "thundra_agent_lambda_trace_integrations_aws_sqs_traceInjection_disable"
+const DisableSNSTraceInjection = "thundra_agent_lambda_trace_integrations_aws_sns_traceInjection_disable"
 
 const MaxTracedHttpBodySize = 128 * 1024
 const ThundraLambdaReportRestCompositeBatchSize = "thundra_agent_lambda_report_rest_composite_batchsize"
@@ -67,6 +132,10 @@ const ThundraLambdaReportCloudwatchCompositeEnable = "thundra_agent_lambda_repor
 
 const ThundraLambdaReportRestCompositeBatchSizeDefault = 100
 const ThundraLambdaReportCloudwatchCompositeBatchSizeDefault = 100
+const ThundraLambdaReportRestMaxBytes = "thundra_agent_lambda_report_rest_maxbytes"
+const ThundraLambdaReportCloudwatchMaxBytes = "thundra_agent_lambda_report_cloudwatch_maxbytes"
+const ThundraLambdaReportRestMaxBytesDefault = 1024 * 1024
+const ThundraLambdaReportCloudwatchMaxBytesDefault = 250 * 1024
 const DefaultMongoDBSizeLimit = 128 * 1024
 
 const ThundraAgentMetricTimeAwareSamplerTimeFreq = "thundra_agent_lambda_metric_sample_sampler_timeAware_timeFreq"
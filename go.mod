@@ -16,4 +16,5 @@ require (
 	github.com/stretchr/testify v1.6.1
 	github.com/apex/gateway v1.1.2
 	github.com/apex/gateway/v2 v2.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -6,6 +6,7 @@ import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
@@ -46,7 +47,7 @@ func (ip *invocationPlugin) prepareData(ctx context.Context) invocationDataModel
 	tags := ip.prepareTags(ctx)
 
 	return invocationDataModel{
-		BaseDataModel:       plugin.GetBaseData(),
+		BaseDataModel:       plugin.GetBaseDataWith(config.SettingsFromContext(ctx)),
 		ID:                  utils.GenerateNewID(),
 		Type:                invocationType,
 		TraceID:             plugin.TraceID,
@@ -100,3 +101,52 @@ func (ip *invocationPlugin) prepareTags(ctx context.Context) map[string]interfac
 	tags[constants.AwsRegion] = application.FunctionRegion
 	return tags
 }
+
+// ProtoDataField returns the field number of the invocation data
+func (d invocationDataModel) ProtoDataField() int {
+	return plugin.ProtoInvocationData
+}
+
+// MarshalProto appends the Invocation message to b
+func (d invocationDataModel) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoMessage(b, 1, d.BaseDataModel.MarshalProto)
+	b = plugin.AppendProtoString(b, 2, d.ID)
+	b = plugin.AppendProtoString(b, 3, d.Type)
+	b = plugin.AppendProtoString(b, 4, d.TraceID)
+	b = plugin.AppendProtoString(b, 5, d.TransactionID)
+	b = plugin.AppendProtoString(b, 6, d.SpanID)
+	b = plugin.AppendProtoString(b, 7, d.ApplicationPlatform)
+	b = plugin.AppendProtoString(b, 8, d.FunctionRegion)
+	b = plugin.AppendProtoInt64(b, 9, d.StartTimestamp)
+	b = plugin.AppendProtoInt64(b, 10, d.FinishTimestamp)
+	b = plugin.AppendProtoInt64(b, 11, d.Duration)
+	b = plugin.AppendProtoBool(b, 12, d.Erroneous)
+	b = plugin.AppendProtoString(b, 13, d.ErrorType)
+	b = plugin.AppendProtoString(b, 14, d.ErrorMessage)
+	b = plugin.AppendProtoString(b, 15, d.ErrorCode)
+	b = plugin.AppendProtoBool(b, 16, d.ColdStart)
+	b = plugin.AppendProtoBool(b, 17, d.Timeout)
+	b = plugin.AppendProtoTags(b, 18, d.Tags)
+	b = plugin.AppendProtoTags(b, 19, d.UserTags)
+	b = plugin.AppendProtoStrings(b, 20, d.IncomingTraceLinks)
+	b = plugin.AppendProtoStrings(b, 21, d.OutgoingTraceLinks)
+	for i := range d.Resources {
+		b = plugin.AppendProtoMessage(b, 22, d.Resources[i].MarshalProto)
+	}
+	return b
+}
+
+// MarshalProto appends the Resource message to b
+func (r *Resource) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoString(b, 1, r.ResourceType)
+	b = plugin.AppendProtoString(b, 2, r.ResourceName)
+	b = plugin.AppendProtoString(b, 3, r.ResourceOperation)
+	b = plugin.AppendProtoInt64(b, 4, int64(r.ResourceCount))
+	b = plugin.AppendProtoInt64(b, 5, int64(r.ResourceErrorCount))
+	b = plugin.AppendProtoInt64(b, 6, r.ResourceDuration)
+	b = plugin.AppendProtoInt64(b, 7, r.ResourceMaxDuration)
+	b = plugin.AppendProtoDouble(b, 8, r.ResourceAvgDuration)
+	b = plugin.AppendProtoInt64(b, 9, int64(r.ResourceBlockedCount))
+	b = plugin.AppendProtoInt64(b, 10, int64(r.ResourceViolatedCount))
+	return plugin.AppendProtoStrings(b, 11, r.ResourceErrors)
+}
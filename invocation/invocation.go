@@ -52,6 +52,15 @@ func (ip *invocationPlugin) BeforeExecution(ctx context.Context, request json.Ra
 	}
 
 	setInvocationTriggerTags(ctx, request)
+	if plugin.DroppedBatchCount > 0 {
+		SetAgentTag(constants.ThundraAgentReportDroppedBatches, plugin.DroppedBatchCount)
+	}
+	if plugin.LastReportLatency > 0 {
+		SetAgentTag(constants.ThundraAgentReportLatency, plugin.LastReportLatency)
+	}
+	for tag, value := range plugin.ReporterTelemetry {
+		SetAgentTag(tag, value)
+	}
 	if GetAgentTag(constants.SpanTags["TRIGGER_CLASS_NAME"]) != nil {
 		triggerClassName, ok := GetAgentTag(constants.SpanTags["TRIGGER_CLASS_NAME"]).(string)
 		if ok {
@@ -10,6 +10,7 @@ import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
@@ -28,6 +29,43 @@ func TestInvocationData_BeforeExecution(t *testing.T) {
 	assert.True(t, prevTime <= ip.data.startTimestamp)
 }
 
+func TestInvocationData_BeforeExecutionWithDroppedBatches(t *testing.T) {
+	plugin.DroppedBatchCount = 3
+	defer func() { plugin.DroppedBatchCount = 0 }()
+	defer Clear()
+
+	ip := New()
+	ip.BeforeExecution(context.TODO(), nil)
+
+	assert.Equal(t, uint64(3), GetAgentTag(constants.ThundraAgentReportDroppedBatches))
+}
+
+func TestInvocationData_BeforeExecutionWithReportLatency(t *testing.T) {
+	plugin.LastReportLatency = 42
+	defer func() { plugin.LastReportLatency = 0 }()
+	defer Clear()
+
+	ip := New()
+	ip.BeforeExecution(context.TODO(), nil)
+
+	assert.Equal(t, int64(42), GetAgentTag(constants.ThundraAgentReportLatency))
+}
+
+func TestInvocationData_BeforeExecutionWithReporterTelemetry(t *testing.T) {
+	plugin.ReporterTelemetry = map[string]interface{}{
+		constants.ThundraAgentReportSentBatches:   uint64(5),
+		constants.ThundraAgentReportStatusClasses: map[string]uint64{"2xx": 5},
+	}
+	defer func() { plugin.ReporterTelemetry = nil }()
+	defer Clear()
+
+	ip := New()
+	ip.BeforeExecution(context.TODO(), nil)
+
+	assert.Equal(t, uint64(5), GetAgentTag(constants.ThundraAgentReportSentBatches))
+	assert.Equal(t, map[string]uint64{"2xx": 5}, GetAgentTag(constants.ThundraAgentReportStatusClasses))
+}
+
 func TestInvocationData_AfterExecution(t *testing.T) {
 	ip := New()
 	invocationCount = 0
@@ -11,8 +11,11 @@ import (
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
 
@@ -59,6 +62,11 @@ type eventTypeKey key
 
 var void struct{}
 
+var incomingSpanContext *tracer.SpanContext
+
+// incomingPropagationFormat is the propagation format selected by the settings of the agent for the invocation
+var incomingPropagationFormat tracer.PropagationFormat
+
 func injectTriggerTagsToInvocation(domainName string, className string, operationNames []string) {
 	SetAgentTag(constants.SpanTags["TRIGGER_DOMAIN_NAME"], domainName)
 	SetAgentTag(constants.SpanTags["TRIGGER_CLASS_NAME"], className)
@@ -257,6 +265,15 @@ func injectTriggerTagsForSNS(payload json.RawMessage) {
 		if record.SNS.MessageID != "" {
 			traceLinks = append(traceLinks, record.SNS.MessageID)
 		}
+		carrier := opentracing.TextMapCarrier{}
+		for k, v := range record.SNS.MessageAttributes {
+			if attribute, ok := v.(map[string]interface{}); ok {
+				if value, ok := attribute["Value"].(string); ok {
+					carrier[k] = value
+				}
+			}
+		}
+		setIncomingSpanContext(carrier)
 	}
 
 	var topicNames []string
@@ -287,6 +304,13 @@ func injectTriggerTagsForSQS(payload json.RawMessage) {
 		if record.MessageId != "" {
 			traceLinks = append(traceLinks, record.MessageId)
 		}
+		carrier := opentracing.TextMapCarrier{}
+		for k, v := range record.MessageAttributes {
+			if v.StringValue != nil {
+				carrier[k] = *v.StringValue
+			}
+		}
+		setIncomingSpanContext(carrier)
 	}
 
 	var queueNames []string
@@ -368,6 +392,7 @@ func injectTriggerTagsForAPIGateway(payload json.RawMessage) {
 
 	var operationNames = []string{path}
 
+	setIncomingSpanContext(opentracing.TextMapCarrier(e.Params.Header))
 	injectTriggerTagsToInvocation(domainName, className, operationNames)
 }
 
@@ -388,6 +413,7 @@ func injectTriggerTagsForAPIGatewayProxy(payload json.RawMessage) {
 		if spanID != "" {
 			AddIncomingTraceLinks([]string{spanID})
 		}
+		setIncomingSpanContext(opentracing.TextMapCarrier(e.Headers))
 	}
 
 	injectTriggerTagsToInvocation(domainName, className, operationNames)
@@ -453,10 +479,47 @@ func injectTriggerTagsForLambda(ctx context.Context) {
 }
 
 func setInvocationTriggerTags(ctx context.Context, payload json.RawMessage) {
+	incomingSpanContext = nil
+	incomingPropagationFormat = tracer.PropagationFormatOf(config.SettingsFromContext(ctx))
 	ok := injectTriggerTagsFromInputType(ctx, payload)
 	if !ok {
 		injectTriggerTagsFromPayload(ctx, payload)
 	}
+	joinIncomingTrace(ctx)
+}
+
+// setIncomingSpanContext extracts the trace context from the given carrier
+// using the propagation format selected by the settings of the agent.
+// Only the first span context found in the trigger event is kept.
+func setIncomingSpanContext(carrier opentracing.TextMapReader) {
+	if incomingSpanContext != nil {
+		return
+	}
+	format := incomingPropagationFormat
+	if format == "" {
+		format = tracer.ConfiguredPropagationFormat()
+	}
+	sc, err := tracer.ExtractSpanContext(format, carrier)
+	if err != nil {
+		return
+	}
+	spanContext, ok := sc.(tracer.SpanContext)
+	if ok {
+		incomingSpanContext = &spanContext
+	}
+}
+
+// joinIncomingTrace makes the root span in ctx a child of the incoming span context
+// unless the root span has already been bound to a parent
+func joinIncomingTrace(ctx context.Context) {
+	if incomingSpanContext == nil {
+		return
+	}
+	rootSpan := opentracing.SpanFromContext(ctx)
+	if rootSpan == nil {
+		return
+	}
+	tracer.ContinueTrace(rootSpan, *incomingSpanContext)
 }
 
 func injectTriggerTagsFromInputType(ctx context.Context, payload json.RawMessage) bool {
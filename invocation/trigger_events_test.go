@@ -2,15 +2,20 @@ package invocation
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"reflect"
 	"testing"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go/aws"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -119,6 +124,37 @@ func TestInvocationTags_SQSTriggerFromInputType(t *testing.T) {
 	assert.ElementsMatch(t, traceLinks, []string{"MessageID_1"})
 }
 
+func TestInvocationTags_SQSTriggerWithTraceContext(t *testing.T) {
+	Clear()
+	clearTraceLinks()
+
+	e := events.SQSEvent{
+		Records: []events.SQSMessage{
+			{
+				MessageId:      "MessageID_1",
+				EventSource:    "aws:sqs",
+				EventSourceARN: "arn:aws:sqs:us-west-2:123456789012:SQSQueue",
+				MessageAttributes: map[string]events.SQSMessageAttribute{
+					"traceparent": {
+						StringValue: aws.String("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"),
+						DataType:    "String",
+					},
+				},
+			},
+		},
+	}
+	eventMock, _ := json.Marshal(e)
+
+	rootSpan := tracer.New(tracer.NewInMemoryRecorder()).StartSpan("root")
+	ctx := opentracing.ContextWithSpan(context.Background(), rootSpan)
+	setInvocationTriggerTags(ctx, eventMock)
+
+	rawRootSpan, _ := tracer.GetRaw(rootSpan)
+	assert.Equal(t, "0af76519-16cd-43dd-8448-eb211c80319c", rawRootSpan.Context.TraceID)
+	assert.Equal(t, "0af76519-16cd-43dd-8448-eb211c80319c", plugin.TraceID)
+	assert.Equal(t, "b7ad6b7169203331", rawRootSpan.ParentSpanID)
+}
+
 func TestInvocationTags_CFTrigger(t *testing.T) {
 	Clear()
 
@@ -1,6 +1,9 @@
 package log
 
 import (
+	"context"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
@@ -30,9 +33,9 @@ type monitoringLog struct {
 	spanID         string
 }
 
-func prepareLogData(log *monitoringLog) logData {
+func prepareLogData(ctx context.Context, log *monitoringLog) logData {
 	return logData{
-		BaseDataModel:  plugin.GetBaseData(),
+		BaseDataModel:  plugin.GetBaseDataWith(config.SettingsFromContext(ctx)),
 		ID:             utils.GenerateNewID(),
 		Type:           logType,
 		TraceID:        plugin.TraceID,
@@ -46,3 +49,24 @@ func prepareLogData(log *monitoringLog) logData {
 		Tags:           map[string]interface{}{},
 	}
 }
+
+// ProtoDataField returns the field number of the log data
+func (d logData) ProtoDataField() int {
+	return plugin.ProtoLogData
+}
+
+// MarshalProto appends the Log message to b
+func (d logData) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoMessage(b, 1, d.BaseDataModel.MarshalProto)
+	b = plugin.AppendProtoString(b, 2, d.ID)
+	b = plugin.AppendProtoString(b, 3, d.Type)
+	b = plugin.AppendProtoString(b, 4, d.TraceID)
+	b = plugin.AppendProtoString(b, 5, d.TransactionID)
+	b = plugin.AppendProtoString(b, 6, d.SpanID)
+	b = plugin.AppendProtoString(b, 7, d.LogMessage)
+	b = plugin.AppendProtoString(b, 8, d.LogContextName)
+	b = plugin.AppendProtoInt64(b, 9, d.LogTimestamp)
+	b = plugin.AppendProtoString(b, 10, d.LogLevel)
+	b = plugin.AppendProtoInt64(b, 11, int64(d.LogLevelCode))
+	return plugin.AppendProtoTags(b, 12, d.Tags)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
 )
 
 type logPlugin struct{}
@@ -20,6 +21,11 @@ func (p *logPlugin) IsEnabled() bool {
 	return !config.LogDisabled
 }
 
+// IsEnabledWith returns whether the plugin is enabled with the settings of the agent
+func (p *logPlugin) IsEnabledWith(s *config.Settings) bool {
+	return !s.LogDisabled
+}
+
 func (p *logPlugin) Order() uint8 {
 	return pluginOrder
 }
@@ -32,8 +38,8 @@ func (p *logPlugin) BeforeExecution(ctx context.Context, request json.RawMessage
 func (p *logPlugin) AfterExecution(ctx context.Context, request json.RawMessage, response interface{}, err interface{}) ([]plugin.MonitoringDataWrapper, context.Context) {
 	var collectedData []plugin.MonitoringDataWrapper
 	for _, l := range logManager.logs {
-		data := prepareLogData(l)
-		sampler := GetSampler()
+		data := prepareLogData(ctx, l)
+		sampler := samplers.FromContext(ctx, logType, GetSampler())
 		if sampler == nil || sampler.IsSampled(data) {
 			collectedData = append(collectedData, plugin.WrapMonitoringData(data, logType))
 		}
@@ -44,8 +50,8 @@ func (p *logPlugin) AfterExecution(ctx context.Context, request json.RawMessage,
 func (p *logPlugin) OnPanic(ctx context.Context, request json.RawMessage, err interface{}, stackTrace []byte) []plugin.MonitoringDataWrapper {
 	var collectedData []plugin.MonitoringDataWrapper
 	for _, l := range logManager.logs {
-		data := prepareLogData(l)
-		sampler := GetSampler()
+		data := prepareLogData(ctx, l)
+		sampler := samplers.FromContext(ctx, logType, GetSampler())
 		if sampler == nil || sampler.IsSampled(data) {
 			collectedData = append(collectedData, plugin.WrapMonitoringData(data, logType))
 		}
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,7 +9,7 @@ import (
 
 func TestPrepareCPUMetricsData(t *testing.T) {
 	mp := New()
-	base := mp.prepareMetricsData()
+	base := mp.prepareMetricsData(context.TODO())
 	cpuStatsData := prepareCPUMetricsData(mp, base)
 
 	assert.True(t, len(cpuStatsData.ID) != 0)
@@ -1,7 +1,10 @@
 package metric
 
 import (
+	"context"
+
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 )
 
@@ -19,9 +22,9 @@ type metricDataModel struct {
 	Tags            map[string]interface{} `json:"tags"`
 }
 
-func (mp *metricPlugin) prepareMetricsData() metricDataModel {
+func (mp *metricPlugin) prepareMetricsData(ctx context.Context) metricDataModel {
 	return metricDataModel{
-		BaseDataModel:   plugin.GetBaseData(),
+		BaseDataModel:   plugin.GetBaseDataWith(config.SettingsFromContext(ctx)),
 		Type:            metricType,
 		TraceID:         plugin.TraceID,
 		TransactionID:   plugin.TransactionID,
@@ -33,3 +36,22 @@ func (mp *metricPlugin) prepareMetricsData() metricDataModel {
 		},
 	}
 }
+
+// ProtoDataField returns the field number of the metric data
+func (d metricDataModel) ProtoDataField() int {
+	return plugin.ProtoMetricData
+}
+
+// MarshalProto appends the Metric message to b
+func (d metricDataModel) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoMessage(b, 1, d.BaseDataModel.MarshalProto)
+	b = plugin.AppendProtoString(b, 2, d.ID)
+	b = plugin.AppendProtoString(b, 3, d.Type)
+	b = plugin.AppendProtoString(b, 4, d.TraceID)
+	b = plugin.AppendProtoString(b, 5, d.TransactionID)
+	b = plugin.AppendProtoString(b, 6, d.SpanID)
+	b = plugin.AppendProtoString(b, 7, d.MetricName)
+	b = plugin.AppendProtoInt64(b, 8, d.MetricTimestamp)
+	b = plugin.AppendProtoTags(b, 9, d.Metrics)
+	return plugin.AppendProtoTags(b, 10, d.Tags)
+}
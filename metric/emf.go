@@ -0,0 +1,146 @@
+package metric
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+// emfWriter is where the EMF documents are written. CloudWatch Logs extracts
+// the metrics from the documents written to the standard output.
+var emfWriter io.Writer = os.Stdout
+
+// emfUnits holds the CloudWatch units of the metrics. Metrics which are not
+// listed here are reported without a unit.
+var emfUnits = map[string]string{
+	pauseTotalNs:      "None",
+	pauseNs:           "None",
+	numGc:             "Count",
+	nextGc:            "Bytes",
+	deltaNumGc:        "Count",
+	deltaPauseTotalNs: "None",
+	readBytes:         "Bytes",
+	writeBytes:        "Bytes",
+	readCount:         "Count",
+	writeCount:        "Count",
+	numGoroutine:      "Count",
+	heapAlloc:         "Bytes",
+	heapSys:           "Bytes",
+	heapInuse:         "Bytes",
+	heapObjects:       "Count",
+	memoryPercent:     "Percent",
+	bytesRecv:         "Bytes",
+	bytesSent:         "Bytes",
+	packetsRecv:       "Count",
+	packetsSent:       "Count",
+	errIn:             "Count",
+	errOut:            "Count",
+	appUsedMemory:     "Bytes",
+	appMaxMemory:      "Bytes",
+	sysUsedMemory:     "Bytes",
+	sysMaxMemory:      "Bytes",
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string                `json:"Namespace"`
+	Dimensions [][]string            `json:"Dimensions"`
+	Metrics    []emfMetricDefinition `json:"Metrics"`
+}
+
+type emfMetricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit,omitempty"`
+}
+
+// emfEnabled returns whether metrics are written in CloudWatch Embedded Metric Format
+// instead of being sent through the reporter
+func emfEnabled(s *config.Settings) bool {
+	return s.ReportCloudwatchEnabled && s.MetricEMFEnabled
+}
+
+// writeEMF writes each of the metric data in stats as a CloudWatch EMF document
+func writeEMF(s *config.Settings, stats []plugin.MonitoringDataWrapper) {
+	dimensions := emfDimensions(s)
+	dimensionNames := make([]string, 0, len(dimensions))
+	for _, name := range append([]string{"FunctionName", "Stage"}, s.MetricEMFDimensions...) {
+		if _, ok := dimensions[name]; ok && !utils.StringContains(dimensionNames, name) {
+			dimensionNames = append(dimensionNames, name)
+		}
+	}
+
+	for _, stat := range stats {
+		data, ok := stat.Data.(metricDataModel)
+		if !ok {
+			continue
+		}
+		b, err := json.Marshal(prepareEMFDocument(data, s.MetricEMFNamespace, dimensions, dimensionNames))
+		if err != nil {
+			log.Println("Error in marshalling EMF document:", err)
+			continue
+		}
+		fmt.Fprintln(emfWriter, string(b))
+	}
+}
+
+func prepareEMFDocument(data metricDataModel, namespace string, dimensions map[string]string, dimensionNames []string) map[string]interface{} {
+	doc := map[string]interface{}{
+		"metricName":    data.MetricName,
+		"traceId":       data.TraceID,
+		"transactionId": data.TransactionID,
+	}
+	for name, value := range dimensions {
+		doc[name] = value
+	}
+
+	names := make([]string, 0, len(data.Metrics))
+	for name, value := range data.Metrics {
+		doc[name] = value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	definitions := make([]emfMetricDefinition, 0, len(names))
+	for _, name := range names {
+		definitions = append(definitions, emfMetricDefinition{Name: name, Unit: emfUnits[name]})
+	}
+
+	doc["_aws"] = emfMetadata{
+		Timestamp: data.MetricTimestamp,
+		CloudWatchMetrics: []emfMetricDirective{{
+			Namespace:  namespace,
+			Dimensions: [][]string{dimensionNames},
+			Metrics:    definitions,
+		}},
+	}
+	return doc
+}
+
+// emfDimensions returns the values of the dimensions which are available for the function.
+// Configured dimensions other than FunctionName and Stage are read from the application tags.
+func emfDimensions(s *config.Settings) map[string]string {
+	dimensions := map[string]string{}
+	if application.FunctionName != "" {
+		dimensions["FunctionName"] = application.FunctionName
+	}
+	if application.ApplicationStage != "" {
+		dimensions["Stage"] = application.ApplicationStage
+	}
+	for _, name := range s.MetricEMFDimensions {
+		if value, ok := application.ApplicationTags[name]; ok {
+			dimensions[name] = fmt.Sprint(value)
+		}
+	}
+	return dimensions
+}
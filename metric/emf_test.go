@@ -0,0 +1,87 @@
+package metric
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+)
+
+func TestWriteEMF(t *testing.T) {
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+	application.ApplicationTags = map[string]interface{}{"team": "payments"}
+	defer func() { application.ApplicationTags = map[string]interface{}{} }()
+	config.MetricEMFNamespace = "Thundra/Test"
+	config.MetricEMFDimensions = []string{"team", "missing"}
+	defer func() { config.MetricEMFDimensions = nil }()
+
+	var out bytes.Buffer
+	emfWriter = &out
+	defer func() { emfWriter = os.Stdout }()
+
+	data := metricDataModel{
+		MetricName:      heapMetric,
+		TraceID:         "trace-id",
+		MetricTimestamp: 1500,
+		Metrics:         map[string]interface{}{heapAlloc: uint64(1024), memoryPercent: 12.5},
+	}
+	s := config.CurrentSettings()
+	writeEMF(&s, []plugin.MonitoringDataWrapper{plugin.WrapMonitoringData(data, metricType)})
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, test.FunctionName, doc["FunctionName"])
+	assert.Equal(t, test.ApplicationStage, doc["Stage"])
+	assert.Equal(t, "payments", doc["team"])
+	assert.Equal(t, 1024.0, doc[heapAlloc])
+	assert.Equal(t, "trace-id", doc["traceId"])
+
+	b, _ := json.Marshal(doc["_aws"])
+	var metadata emfMetadata
+	assert.Nil(t, json.Unmarshal(b, &metadata))
+	assert.Equal(t, int64(1500), metadata.Timestamp)
+	directive := metadata.CloudWatchMetrics[0]
+	assert.Equal(t, "Thundra/Test", directive.Namespace)
+	assert.Equal(t, [][]string{{"FunctionName", "Stage", "team"}}, directive.Dimensions)
+	assert.Equal(t, []emfMetricDefinition{{Name: heapAlloc, Unit: "Bytes"}, {Name: memoryPercent, Unit: "Percent"}}, directive.Metrics)
+}
+
+func TestMetric_AfterExecutionWithEMF(t *testing.T) {
+	config.ReportCloudwatchEnabled = true
+	config.MetricEMFEnabled = true
+	defer func() {
+		config.ReportCloudwatchEnabled = false
+		config.MetricEMFEnabled = false
+	}()
+
+	var out bytes.Buffer
+	emfWriter = &out
+	defer func() { emfWriter = os.Stdout }()
+
+	sampler := GetSampler()
+	SetSampler(nil)
+	defer SetSampler(sampler)
+
+	mp := New()
+	mp.disableCPUMetrics = true
+	mp.disableDiskMetrics = true
+	mp.disableNetMetrics = true
+	mp.disableMemoryMetrics = true
+	mp.BeforeExecution(context.TODO(), json.RawMessage{})
+	stats, _ := mp.AfterExecution(context.TODO(), json.RawMessage{}, nil, nil)
+
+	assert.Equal(t, 3, len(stats))
+	for _, stat := range stats {
+		assert.True(t, stat.LocalOnly)
+	}
+	assert.Equal(t, 3, len(strings.Split(strings.TrimSpace(out.String()), "\n")))
+}
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"context"
 	"runtime"
 	"testing"
 
@@ -17,7 +18,7 @@ func TestPrepareGCMetricsData(t *testing.T) {
 	makeMultipleGCCalls(garbageCollectionCount)
 	memStats := &runtime.MemStats{}
 	runtime.ReadMemStats(memStats)
-	base := mp.prepareMetricsData()
+	base := mp.prepareMetricsData(context.TODO())
 	gcStatsData := prepareGCMetricsData(mp, memStats, base)
 
 	assert.True(t, len(gcStatsData.ID) != 0)
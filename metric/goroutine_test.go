@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,7 +22,7 @@ func TestPrepareGoroutineMetricsData(t *testing.T) {
 
 	done := make(chan bool)
 	generateGoroutines(done, numGoroutines)
-	base := mp.prepareMetricsData()
+	base := mp.prepareMetricsData(context.TODO())
 	grMetric := prepareGoRoutineMetricsData(mp, base)
 
 	assert.True(t, len(grMetric.ID) != 0)
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"context"
 	"runtime"
 	"testing"
 
@@ -11,7 +12,7 @@ func TestPrepareHeapMetricsData(t *testing.T) {
 	mp := New()
 
 	memStats := &runtime.MemStats{}
-	base := mp.prepareMetricsData()
+	base := mp.prepareMetricsData(context.TODO())
 	heapMetricsData := prepareHeapMetricsData(mp, memStats, base)
 
 	assert.True(t, len(heapMetricsData.ID) != 0)
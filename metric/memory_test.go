@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"context"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"testing"
 
@@ -10,7 +11,7 @@ import (
 func TestPrepareMemoryMetricsData(t *testing.T) {
 	mp := New()
 
-	base := mp.prepareMetricsData()
+	base := mp.prepareMetricsData(context.TODO())
 	memoryMetricsData := prepareMemoryMetricsData(mp, base)
 
 	assert.True(t, len(memoryMetricsData.ID) != 0)
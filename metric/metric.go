@@ -10,6 +10,7 @@ import (
 	"github.com/shirou/gopsutil/net"
 	"github.com/shirou/gopsutil/process"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
 
@@ -57,6 +58,11 @@ func (mp *metricPlugin) IsEnabled() bool {
 	return !config.MetricDisabled
 }
 
+// IsEnabledWith returns whether the plugin is enabled with the settings of the agent
+func (mp *metricPlugin) IsEnabledWith(s *config.Settings) bool {
+	return !s.MetricDisabled
+}
+
 func (mp *metricPlugin) Order() uint8 {
 	return pluginOrder
 }
@@ -94,10 +100,10 @@ func (mp *metricPlugin) AfterExecution(ctx context.Context, request json.RawMess
 
 	var stats []plugin.MonitoringDataWrapper
 
-	base := mp.prepareMetricsData()
+	base := mp.prepareMetricsData(ctx)
 
-	if GetSampler() != nil {
-		if !GetSampler().IsSampled(base) {
+	if sampler := samplers.FromContext(ctx, metricType, GetSampler()); sampler != nil {
+		if !sampler.IsSampled(base) {
 			return stats, ctx
 		}
 	}
@@ -147,5 +153,14 @@ func (mp *metricPlugin) AfterExecution(ctx context.Context, request json.RawMess
 		stats = append(stats, plugin.WrapMonitoringData(mm, metricType))
 	}
 
+	if s := config.SettingsFromContext(ctx); emfEnabled(s) {
+		// CloudWatch extracts the metrics from the logs, so they are not reported
+		// but they are still sent to StatsD if it is enabled
+		writeEMF(s, stats)
+		for i := range stats {
+			stats[i].LocalOnly = true
+		}
+	}
+
 	return stats, ctx
 }
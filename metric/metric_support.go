@@ -1,8 +1,14 @@
 package metric
 
-import "github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
+import (
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
+)
 
-var _sampler = samplers.NewCompositeSampler([]samplers.Sampler{samplers.NewTimeAwareSampler(), samplers.NewCountAwareSampler()}, "or")
+var _sampler = newDefaultSampler()
+
+// customSampler is true if the sampler is set by SetSampler, so it is not rebuilt when the settings are reloaded
+var customSampler bool
 
 func GetSampler() samplers.Sampler {
 	return _sampler
@@ -10,4 +16,18 @@ func GetSampler() samplers.Sampler {
 
 func SetSampler(sampler samplers.Sampler) {
 	_sampler = sampler
+	customSampler = true
+}
+
+// newDefaultSampler returns a sampler using the sampling frequencies in the settings
+func newDefaultSampler() samplers.Sampler {
+	return samplers.NewCompositeSampler([]samplers.Sampler{samplers.NewTimeAwareSampler(), samplers.NewCountAwareSampler()}, "or")
+}
+
+func init() {
+	config.OnReload(func() {
+		if !customSampler {
+			_sampler = newDefaultSampler()
+		}
+	})
 }
@@ -72,8 +72,15 @@ func PrepareBaseData() BaseDataModel {
 }
 
 func GetBaseData() BaseDataModel {
-	if (config.ReportRestCompositeDataEnabled && !config.ReportCloudwatchEnabled) ||
-		(config.ReportCloudwatchEnabled && config.ReportCloudwatchCompositeDataEnabled) {
+	s := config.CurrentSettings()
+	return GetBaseDataWith(&s)
+}
+
+// GetBaseDataWith returns the base data of the monitoring data reported with the given settings.
+// It is empty if the data is reported in composite data which carries the base data once.
+func GetBaseDataWith(s *config.Settings) BaseDataModel {
+	if (s.ReportRestCompositeDataEnabled && !s.ReportCloudwatchEnabled) ||
+		(s.ReportCloudwatchEnabled && s.ReportCloudwatchCompositeDataEnabled) {
 		return BaseDataModel{}
 	}
 	return PrepareBaseData()
@@ -13,6 +13,15 @@ var TraceID string
 var TransactionID string
 var TriggerClassName string
 
+// DroppedBatchCount is the number of monitoring data batches the agent could not report since the start of the container
+var DroppedBatchCount uint64
+
+// LastReportLatency is the time in milliseconds the agent spent to report the data of the previous invocation
+var LastReportLatency int64
+
+// ReporterTelemetry is the counters of the reporter since the start of the container keyed by their agent tag names
+var ReporterTelemetry map[string]interface{}
+
 // Plugin interface provides necessary methods for the plugins to be used in thundra agent
 type Plugin interface {
 	BeforeExecution(ctx context.Context, request json.RawMessage) context.Context
@@ -21,6 +30,20 @@ type Plugin interface {
 	Order() uint8
 }
 
+// SettingsPlugin is implemented by the plugins which are enabled or disabled by the settings of the agent
+// they are added to. IsEnabled of these plugins follows the current settings.
+type SettingsPlugin interface {
+	IsEnabledWith(s *config.Settings) bool
+}
+
+// IsEnabledWith returns whether the plugin is enabled with the given settings
+func IsEnabledWith(p Plugin, s *config.Settings) bool {
+	if sp, ok := p.(SettingsPlugin); ok {
+		return sp.IsEnabledWith(s)
+	}
+	return p.IsEnabled()
+}
+
 type Data interface{}
 
 // MonitoringDataWrapper defines the structure that given dataformat follows by Thundra. In here data could be a trace, metric or log data.
@@ -29,7 +52,10 @@ type MonitoringDataWrapper struct {
 	Type             string `json:"type"`
 	Data             Data   `json:"data"`
 	APIKey           string `json:"apiKey"`
-	Compressed       bool   `json:"compressed"`
+	Compressed       bool   `json:"compressed"` // Data is gzipped and base64 encoded JSON
+	// LocalOnly data is not reported, it is only sent to the local sinks such as StatsD.
+	// The metrics written in CloudWatch Embedded Metric Format are marked so.
+	LocalOnly bool `json:"-"`
 }
 
 func WrapMonitoringData(data interface{}, dataType string) MonitoringDataWrapper {
@@ -0,0 +1,281 @@
+package plugin
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// The protobuf schema of the monitoring data is in thundra.proto. The messages are encoded
+// with the functions below instead of generated code to keep the agent free of dependencies.
+
+// Field numbers of the data kinds in the Data message
+const (
+	ProtoInvocationData = 1
+	ProtoTraceData      = 2
+	ProtoSpanData       = 3
+	ProtoMetricData     = 4
+	ProtoLogData        = 5
+	ProtoCompositeData  = 6
+	// Data which does not implement ProtoMarshaler is sent as JSON
+	ProtoJSONData = 15
+)
+
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+)
+
+// ProtoMarshaler is implemented by the data models which can be encoded in protobuf
+type ProtoMarshaler interface {
+	// ProtoDataField returns the field number of the data in the Data message
+	ProtoDataField() int
+	// MarshalProto appends the protobuf encoding of the data to b
+	MarshalProto(b []byte) []byte
+}
+
+// MarshalProto appends the MonitoringData message of the wrapper to b
+func (w MonitoringDataWrapper) MarshalProto(b []byte) []byte {
+	b = AppendProtoString(b, 1, w.DataModelVersion)
+	b = AppendProtoString(b, 2, w.Type)
+	b = AppendProtoMessage(b, 3, func(b []byte) []byte {
+		return appendProtoData(b, w.Data)
+	})
+	b = AppendProtoString(b, 4, w.APIKey)
+	return AppendProtoBool(b, 5, w.Compressed)
+}
+
+// MarshalProtoList appends the MonitoringDataList message of the wrappers to b
+func MarshalProtoList(b []byte, wrappers []MonitoringDataWrapper) []byte {
+	for i := range wrappers {
+		b = AppendProtoMessage(b, 1, wrappers[i].MarshalProto)
+	}
+	return b
+}
+
+// appendProtoData appends the fields of the Data message. The data which can not be
+// encoded in protobuf, such as the data trimmed to fit in a batch, is sent as JSON.
+func appendProtoData(b []byte, data Data) []byte {
+	if m, ok := data.(ProtoMarshaler); ok {
+		return AppendProtoMessage(b, m.ProtoDataField(), m.MarshalProto)
+	}
+	if data == nil {
+		return b
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return b
+	}
+	return AppendProtoBytes(b, ProtoJSONData, jsonData)
+}
+
+// ProtoDataField returns the field number of the composite data
+func (c CompositeDataModel) ProtoDataField() int {
+	return ProtoCompositeData
+}
+
+// MarshalProto appends the Composite message to b
+func (c CompositeDataModel) MarshalProto(b []byte) []byte {
+	b = AppendProtoMessage(b, 1, c.BaseDataModel.MarshalProto)
+	b = AppendProtoString(b, 2, c.ID)
+	b = AppendProtoString(b, 3, c.Type)
+	allData, _ := c.AllMonitoringData.([]Data)
+	for _, data := range allData {
+		data := data
+		b = AppendProtoMessage(b, 4, func(b []byte) []byte {
+			return appendProtoData(b, data)
+		})
+	}
+	return b
+}
+
+// MarshalProto appends the BaseData message to b
+func (d BaseDataModel) MarshalProto(b []byte) []byte {
+	fields := []*string{d.AgentVersion, d.DataModelVersion, d.ApplicationID, d.ApplicationInstanceID,
+		d.ApplicationDomainName, d.ApplicationClassName, d.ApplicationName, d.ApplicationVersion,
+		d.ApplicationStage, d.ApplicationRuntime, d.ApplicationRuntimeVersion}
+	for i, field := range fields {
+		if field != nil {
+			b = AppendProtoString(b, i+1, *field)
+		}
+	}
+	if d.ApplicationTags != nil {
+		b = AppendProtoTags(b, 12, *d.ApplicationTags)
+	}
+	return b
+}
+
+// AppendProtoTags appends the tags as a map<string, Value> field
+func AppendProtoTags(b []byte, num int, tags map[string]interface{}) []byte {
+	for k, v := range tags {
+		k, v := k, v
+		b = AppendProtoMessage(b, num, func(b []byte) []byte {
+			b = AppendProtoString(b, 1, k)
+			return AppendProtoMessage(b, 2, func(b []byte) []byte {
+				return AppendProtoValue(b, v)
+			})
+		})
+	}
+	return b
+}
+
+// AppendProtoValue appends the fields of the Value message. The values other than
+// strings, numbers and booleans are encoded as JSON.
+func AppendProtoValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return b
+	case string:
+		return appendProtoStringAlways(b, 1, v)
+	case int:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case int8:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case int16:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case int32:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case int64:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case uint:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case uint8:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case uint16:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case uint32:
+		return appendProtoVarintAlways(b, 2, uint64(v))
+	case uint64:
+		return appendProtoVarintAlways(b, 2, v)
+	case float32:
+		return appendProtoDoubleAlways(b, 3, float64(v))
+	case float64:
+		return appendProtoDoubleAlways(b, 3, v)
+	case bool:
+		b = appendProtoTag(b, 4, protoWireVarint)
+		if v {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendProtoVarintAlways(b, 2, uint64(i))
+		}
+		if f, err := v.Float64(); err == nil {
+			return appendProtoDoubleAlways(b, 3, f)
+		}
+		return appendProtoStringAlways(b, 1, v.String())
+	}
+	jsonValue, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return AppendProtoBytes(b, 5, jsonValue)
+}
+
+// AppendProtoString appends a string field unless it is empty
+func AppendProtoString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendProtoStringAlways(b, num, s)
+}
+
+// AppendProtoStrings appends a repeated string field
+func AppendProtoStrings(b []byte, num int, values []string) []byte {
+	for _, s := range values {
+		b = appendProtoStringAlways(b, num, s)
+	}
+	return b
+}
+
+// AppendProtoBytes appends a bytes field unless it is empty
+func AppendProtoBytes(b []byte, num int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = appendProtoTag(b, num, protoWireBytes)
+	b = appendProtoVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendProtoInt64 appends an int64 field unless it is zero
+func AppendProtoInt64(b []byte, num int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendProtoVarintAlways(b, num, uint64(v))
+}
+
+// AppendProtoDouble appends a double field unless it is zero
+func AppendProtoDouble(b []byte, num int, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendProtoDoubleAlways(b, num, v)
+}
+
+// AppendProtoBool appends a bool field unless it is false
+func AppendProtoBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return append(appendProtoTag(b, num, protoWireVarint), 1)
+}
+
+// AppendProtoMessage appends an embedded message field whose fields are appended by marshal.
+// The message is encoded in place and its length is inserted before it afterwards.
+func AppendProtoMessage(b []byte, num int, marshal func([]byte) []byte) []byte {
+	b = appendProtoTag(b, num, protoWireBytes)
+	start := len(b)
+	b = marshal(b)
+	n := len(b) - start
+	size := protoVarintSize(uint64(n))
+	for i := 0; i < size; i++ {
+		b = append(b, 0)
+	}
+	copy(b[start+size:], b[start:start+n])
+	// The length is written over the bytes reserved above
+	appendProtoVarint(b[start:start], uint64(n))
+	return b
+}
+
+func appendProtoStringAlways(b []byte, num int, s string) []byte {
+	b = appendProtoTag(b, num, protoWireBytes)
+	b = appendProtoVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendProtoVarintAlways(b []byte, num int, v uint64) []byte {
+	return appendProtoVarint(appendProtoTag(b, num, protoWireVarint), v)
+}
+
+func appendProtoDoubleAlways(b []byte, num int, v float64) []byte {
+	b = appendProtoTag(b, num, protoWireFixed64)
+	bits := math.Float64bits(v)
+	for i := uint(0); i < 8; i++ {
+		b = append(b, byte(bits>>(8*i)))
+	}
+	return b
+}
+
+func appendProtoTag(b []byte, num int, wireType int) []byte {
+	return appendProtoVarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendProtoVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func protoVarintSize(v uint64) int {
+	size := 1
+	for v >= 0x80 {
+		v >>= 7
+		size++
+	}
+	return size
+}
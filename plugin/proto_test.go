@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// protoField is a decoded field of a protobuf message
+type protoField struct {
+	num   int
+	value uint64
+	bytes []byte
+}
+
+func readVarint(t *testing.T, b []byte) (uint64, int) {
+	v, n := binary.Uvarint(b)
+	assert.True(t, n > 0, "invalid varint")
+	return v, n
+}
+
+// decodeProto decodes the fields of a message, keyed by field number in the order they are encoded
+func decodeProto(t *testing.T, b []byte) map[int][]protoField {
+	fields := map[int][]protoField{}
+	for len(b) > 0 {
+		key, n := readVarint(t, b)
+		b = b[n:]
+		f := protoField{num: int(key >> 3)}
+		switch key & 7 {
+		case protoWireVarint:
+			f.value, n = readVarint(t, b)
+			b = b[n:]
+		case protoWireFixed64:
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case protoWireBytes:
+			l, n := readVarint(t, b)
+			b = b[n:]
+			f.bytes = b[:l]
+			b = b[l:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields[f.num] = append(fields[f.num], f)
+	}
+	return fields
+}
+
+func decodeProtoMap(t *testing.T, entries []protoField) map[string]map[int][]protoField {
+	m := map[string]map[int][]protoField{}
+	for _, entry := range entries {
+		fields := decodeProto(t, entry.bytes)
+		m[string(fields[1][0].bytes)] = decodeProto(t, fields[2][0].bytes)
+	}
+	return m
+}
+
+type protoTestData struct {
+	ID string
+}
+
+func (d protoTestData) ProtoDataField() int {
+	return ProtoSpanData
+}
+
+func (d protoTestData) MarshalProto(b []byte) []byte {
+	return AppendProtoString(b, 2, d.ID)
+}
+
+func TestMarshalProtoWrapper(t *testing.T) {
+	wrapper := MonitoringDataWrapper{
+		DataModelVersion: "2.0",
+		Type:             "Span",
+		Data:             protoTestData{ID: "span-id"},
+		APIKey:           "api-key",
+	}
+
+	fields := decodeProto(t, wrapper.MarshalProto(nil))
+	assert.Equal(t, "2.0", string(fields[1][0].bytes))
+	assert.Equal(t, "Span", string(fields[2][0].bytes))
+	assert.Equal(t, "api-key", string(fields[4][0].bytes))
+	assert.Nil(t, fields[5])
+
+	data := decodeProto(t, fields[3][0].bytes)
+	span := decodeProto(t, data[ProtoSpanData][0].bytes)
+	assert.Equal(t, "span-id", string(span[2][0].bytes))
+}
+
+func TestMarshalProtoJSONFallback(t *testing.T) {
+	wrapper := MonitoringDataWrapper{Type: "Span", Data: map[string]interface{}{"id": "span-id"}}
+
+	fields := decodeProto(t, wrapper.MarshalProto(nil))
+	data := decodeProto(t, fields[3][0].bytes)
+	assert.Equal(t, `{"id":"span-id"}`, string(data[ProtoJSONData][0].bytes))
+}
+
+func TestMarshalProtoComposite(t *testing.T) {
+	appName := "app"
+	tags := map[string]interface{}{"env": "prod"}
+	baseData := BaseDataModel{ApplicationName: &appName, ApplicationTags: &tags}
+	composite := PrepareCompositeData(baseData, []MonitoringDataWrapper{
+		{Data: protoTestData{ID: "span-1"}},
+		{Data: protoTestData{ID: "span-2"}},
+	})
+
+	fields := decodeProto(t, MarshalProtoList(nil, []MonitoringDataWrapper{WrapMonitoringData(composite, "Composite")}))
+	assert.Len(t, fields[1], 1)
+	wrapper := decodeProto(t, fields[1][0].bytes)
+	data := decodeProto(t, wrapper[3][0].bytes)
+	c := decodeProto(t, data[ProtoCompositeData][0].bytes)
+
+	base := decodeProto(t, c[1][0].bytes)
+	assert.Equal(t, "app", string(base[7][0].bytes))
+	assert.Nil(t, base[1])
+	applicationTags := decodeProtoMap(t, base[12])
+	assert.Equal(t, "prod", string(applicationTags["env"][1][0].bytes))
+
+	assert.Equal(t, "Composite", string(c[3][0].bytes))
+	assert.Len(t, c[4], 2)
+	for i, id := range []string{"span-1", "span-2"} {
+		d := decodeProto(t, c[4][i].bytes)
+		assert.Equal(t, id, string(decodeProto(t, d[ProtoSpanData][0].bytes)[2][0].bytes))
+	}
+}
+
+func TestAppendProtoTags(t *testing.T) {
+	tags := map[string]interface{}{
+		"string":   "value",
+		"int":      -5,
+		"float":    1.5,
+		"bool":     false,
+		"list":     []string{"a", "b"},
+		"nil":      nil,
+		"longText": strings.Repeat("x", 300),
+	}
+
+	fields := decodeProto(t, AppendProtoTags(nil, 1, tags))
+	values := decodeProtoMap(t, fields[1])
+	assert.Len(t, values, len(tags))
+	assert.Equal(t, "value", string(values["string"][1][0].bytes))
+	assert.Equal(t, int64(-5), int64(values["int"][2][0].value))
+	assert.Equal(t, 1.5, math.Float64frombits(values["float"][3][0].value))
+	assert.Equal(t, uint64(0), values["bool"][4][0].value)
+	assert.Equal(t, `["a","b"]`, string(values["list"][5][0].bytes))
+	assert.Empty(t, values["nil"])
+	// Messages longer than 127 bytes have multi-byte length prefixes
+	assert.Equal(t, strings.Repeat("x", 300), string(values["longText"][1][0].bytes))
+}
+
+func TestAppendProtoScalarsOmitZeroValues(t *testing.T) {
+	var b []byte
+	b = AppendProtoString(b, 1, "")
+	b = AppendProtoInt64(b, 2, 0)
+	b = AppendProtoDouble(b, 3, 0)
+	b = AppendProtoBool(b, 4, false)
+	b = AppendProtoBytes(b, 5, nil)
+	assert.Empty(t, b)
+}
@@ -0,0 +1,21 @@
+package samplers
+
+import "context"
+
+type samplerKey struct {
+	dataType string
+}
+
+// ContextWithSampler returns a copy of ctx carrying the sampler of the monitoring data of the given
+// type such as "Span", "Log" or "Metric". It overrides the sampler set on the plugin of the data.
+func ContextWithSampler(ctx context.Context, dataType string, sampler Sampler) context.Context {
+	return context.WithValue(ctx, samplerKey{dataType}, sampler)
+}
+
+// FromContext returns the sampler of the given data type carried by ctx, otherwise the given sampler
+func FromContext(ctx context.Context, dataType string, defaultSampler Sampler) Sampler {
+	if sampler, ok := ctx.Value(samplerKey{dataType}).(Sampler); ok {
+		return sampler
+	}
+	return defaultSampler
+}
@@ -35,6 +35,14 @@ func Wrap(handler interface{}) interface{} {
 	return agentInstance.Wrap(handler)
 }
 
+// WrapWithOptions wraps the given handler function with an agent configured by the given options
+// instead of the default one, e.g.
+//
+//	thundra.WrapWithOptions(handler, agent.WithTimeoutMargin(500*time.Millisecond))
+func WrapWithOptions(handler interface{}, options ...agent.Option) interface{} {
+	return addDefaultPlugins(agent.New(options...)).Wrap(handler)
+}
+
 func init() {
 	agentInstance = addDefaultPlugins(agent.New())
 }
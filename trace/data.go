@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
@@ -23,7 +25,7 @@ type traceDataModel struct {
 
 func (tr *tracePlugin) prepareTraceDataModel(ctx context.Context, request json.RawMessage, response interface{}) traceDataModel {
 	return traceDataModel{
-		BaseDataModel:   plugin.GetBaseData(),
+		BaseDataModel:   plugin.GetBaseDataWith(config.SettingsFromContext(ctx)),
 		ID:              plugin.TraceID,
 		Type:            traceType,
 		RootSpanID:      tr.RootSpan.Context().(tracer.SpanContext).SpanID,
@@ -58,20 +60,19 @@ type spanLog struct {
 	Timestamp int64       `json:"timestamp"`
 }
 
-func (tr *tracePlugin) prepareSpanDataModel(ctx context.Context, span *tracer.RawSpan) spanDataModel {
-	// If a span have no rootSpanID (other than the root span)
-	// Set rootSpan's ID as the parent ID for that span
-	rootSpanID := tr.RootSpan.Context().(tracer.SpanContext).SpanID
-	if len(span.ParentSpanID) == 0 && span.Context.SpanID != rootSpanID {
-		span.ParentSpanID = rootSpanID
-	}
+func (tr *tracePlugin) prepareSpanDataModel(ctx context.Context, span *tracer.RawSpan, tree spanTree) spanDataModel {
+	span.ParentSpanID = resolveParentSpanID(tr.RootSpan.Context().(tracer.SpanContext).SpanID, span)
+	tags := span.GetTags()
+	tags[constants.SpanTags["DEPTH"]] = tree.depths[span.Context.SpanID]
+	tags[constants.SpanTags["CHILDREN_COUNT"]] = tree.children[span.Context.SpanID]
 	return spanDataModel{
-		BaseDataModel:   plugin.GetBaseData(),
+		BaseDataModel:   plugin.GetBaseDataWith(config.SettingsFromContext(ctx)),
 		ID:              span.Context.SpanID,
 		Type:            spanType,
 		TraceID:         span.Context.TraceID,
 		TransactionID:   span.Context.TransactionID,
 		ParentSpanID:    span.ParentSpanID,
+		SpanOrder:       span.SpanOrder,
 		DomainName:      span.DomainName,
 		ClassName:       span.ClassName,
 		ServiceName:     application.ApplicationName,
@@ -79,7 +80,118 @@ func (tr *tracePlugin) prepareSpanDataModel(ctx context.Context, span *tracer.Ra
 		StartTimestamp:  span.StartTimestamp,
 		FinishTimestamp: span.EndTimestamp,
 		Duration:        span.Duration(),
-		Tags:            span.GetTags(),
-		Logs:            map[string]spanLog{}, // TO DO get logs
+		Tags:            tags,
+		Logs:            prepareSpanLogs(span.Logs),
+	}
+}
+
+// resolveParentSpanID returns the parent of the span in the call tree. The spans other than
+// the root span which have no parent are the children of the root span.
+func resolveParentSpanID(rootSpanID string, span *tracer.RawSpan) string {
+	if len(span.ParentSpanID) == 0 && span.Context.SpanID != rootSpanID {
+		return rootSpanID
 	}
+	return span.ParentSpanID
+}
+
+// spanTree has the depth and the number of children of the spans of an invocation keyed by their IDs
+type spanTree struct {
+	depths   map[string]int
+	children map[string]int
+}
+
+// newSpanTree builds the call tree of the spans. The spans whose parents are not in the
+// spans, such as the root span continuing an upstream trace, are at depth 0.
+func newSpanTree(rootSpanID string, spans []*tracer.RawSpan) spanTree {
+	parents := make(map[string]string, len(spans))
+	for _, span := range spans {
+		parents[span.Context.SpanID] = resolveParentSpanID(rootSpanID, span)
+	}
+	tree := spanTree{
+		depths:   make(map[string]int, len(spans)),
+		children: make(map[string]int, len(spans)),
+	}
+	for _, parent := range parents {
+		if _, ok := parents[parent]; ok {
+			tree.children[parent]++
+		}
+	}
+	for id := range parents {
+		tree.depth(id, parents)
+	}
+	return tree
+}
+
+func (t spanTree) depth(id string, parents map[string]string) int {
+	if depth, ok := t.depths[id]; ok {
+		return depth
+	}
+	// Guards against the cycles in broken parent references
+	t.depths[id] = 0
+	depth := 0
+	if parent := parents[id]; parent != "" {
+		if _, ok := parents[parent]; ok {
+			depth = t.depth(parent, parents) + 1
+		}
+	}
+	t.depths[id] = depth
+	return depth
+}
+
+// ProtoDataField returns the field number of the trace data
+func (d traceDataModel) ProtoDataField() int {
+	return plugin.ProtoTraceData
+}
+
+// MarshalProto appends the Trace message to b
+func (d traceDataModel) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoMessage(b, 1, d.BaseDataModel.MarshalProto)
+	b = plugin.AppendProtoString(b, 2, d.ID)
+	b = plugin.AppendProtoString(b, 3, d.Type)
+	b = plugin.AppendProtoString(b, 4, d.RootSpanID)
+	b = plugin.AppendProtoInt64(b, 5, d.StartTimestamp)
+	b = plugin.AppendProtoInt64(b, 6, d.FinishTimestamp)
+	b = plugin.AppendProtoInt64(b, 7, d.Duration)
+	return plugin.AppendProtoTags(b, 8, d.Tags)
+}
+
+// ProtoDataField returns the field number of the span data
+func (d spanDataModel) ProtoDataField() int {
+	return plugin.ProtoSpanData
+}
+
+// MarshalProto appends the Span message to b
+func (d spanDataModel) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoMessage(b, 1, d.BaseDataModel.MarshalProto)
+	b = plugin.AppendProtoString(b, 2, d.ID)
+	b = plugin.AppendProtoString(b, 3, d.Type)
+	b = plugin.AppendProtoString(b, 4, d.TraceID)
+	b = plugin.AppendProtoString(b, 5, d.TransactionID)
+	b = plugin.AppendProtoString(b, 6, d.ParentSpanID)
+	b = plugin.AppendProtoInt64(b, 7, d.SpanOrder)
+	b = plugin.AppendProtoString(b, 8, d.DomainName)
+	b = plugin.AppendProtoString(b, 9, d.ClassName)
+	b = plugin.AppendProtoString(b, 10, d.ServiceName)
+	b = plugin.AppendProtoString(b, 11, d.OperationName)
+	b = plugin.AppendProtoInt64(b, 12, d.StartTimestamp)
+	b = plugin.AppendProtoInt64(b, 13, d.FinishTimestamp)
+	b = plugin.AppendProtoInt64(b, 14, d.Duration)
+	b = plugin.AppendProtoTags(b, 15, d.Tags)
+	for k, l := range d.Logs {
+		k, l := k, l
+		b = plugin.AppendProtoMessage(b, 16, func(b []byte) []byte {
+			b = plugin.AppendProtoString(b, 1, k)
+			return plugin.AppendProtoMessage(b, 2, l.MarshalProto)
+		})
+	}
+	return b
+}
+
+// MarshalProto appends the SpanLog message to b
+func (l spanLog) MarshalProto(b []byte) []byte {
+	b = plugin.AppendProtoString(b, 1, l.Name)
+	b = plugin.AppendProtoMessage(b, 2, func(b []byte) []byte {
+		return plugin.AppendProtoValue(b, l.Value)
+	})
+	return plugin.AppendProtoInt64(b, 3, l.Timestamp)
 }
@@ -0,0 +1,126 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+)
+
+// benchmarkSpanData returns a span with as many tags as the spans of the AWS SDK and HTTP integrations
+func benchmarkSpanData() plugin.MonitoringDataWrapper {
+	tags := map[string]interface{}{}
+	for i := 0; i < 40; i++ {
+		tags[fmt.Sprintf("aws.dynamodb.tag%d", i)] = fmt.Sprintf("value of the tag number %d", i)
+	}
+	tags["http.status_code"] = 200
+	tags["aws.request.duration"] = 12.5
+	tags["error"] = false
+	tags["topology.vertex"] = true
+
+	return plugin.WrapMonitoringData(spanDataModel{
+		BaseDataModel:   plugin.PrepareBaseData(),
+		ID:              "e2c1d4f0-3a8c-4d6b-9a2f-8b7c6d5e4f3a",
+		Type:            spanType,
+		TraceID:         "5b2b8c4e-1f0a-4e3d-8c7b-6a5f4e3d2c1b",
+		TransactionID:   "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a",
+		ParentSpanID:    "1a2b3c4d-5e6f-4a8b-9c0d-1e2f3a4b5c6d",
+		DomainName:      "DB",
+		ClassName:       "AWS-DynamoDB",
+		ServiceName:     "my-function",
+		OperationName:   "users",
+		StartTimestamp:  1600000000000,
+		FinishTimestamp: 1600000000015,
+		Duration:        15,
+		Tags:            tags,
+		Logs: map[string]spanLog{
+			"event": {Name: "event", Value: "retry", Timestamp: 1600000000010},
+		},
+	}, spanType)
+}
+
+func TestSpanDataMarshalProto(t *testing.T) {
+	wrapper := benchmarkSpanData()
+	b := wrapper.MarshalProto(nil)
+	j, err := json.Marshal(wrapper)
+	assert.Nil(t, err)
+	assert.True(t, len(b) < len(j))
+
+	// Span data is encoded as a message rather than falling back to JSON
+	assert.Contains(t, string(b), "AWS-DynamoDB")
+	assert.NotContains(t, string(b), `"className"`)
+}
+
+func newTestRawSpan(id string, parentID string) *tracer.RawSpan {
+	return &tracer.RawSpan{Context: tracer.SpanContext{SpanID: id}, ParentSpanID: parentID}
+}
+
+func TestNewSpanTree(t *testing.T) {
+	spans := []*tracer.RawSpan{
+		// The root span continues an upstream trace
+		newTestRawSpan("root", "upstream"),
+		newTestRawSpan("a", "root"),
+		newTestRawSpan("a1", "a"),
+		newTestRawSpan("a2", "a"),
+		newTestRawSpan("a11", "a1"),
+		// Spans without a parent are the children of the root span
+		newTestRawSpan("b", ""),
+		newTestRawSpan("c", "unknown"),
+	}
+
+	tree := newSpanTree("root", spans)
+
+	assert.Equal(t, map[string]int{"root": 0, "a": 1, "a1": 2, "a2": 2, "a11": 3, "b": 1, "c": 0}, tree.depths)
+	assert.Equal(t, map[string]int{"root": 2, "a": 2, "a1": 1}, tree.children)
+}
+
+func TestNewSpanTreeCycle(t *testing.T) {
+	spans := []*tracer.RawSpan{
+		newTestRawSpan("root", ""),
+		newTestRawSpan("a", "b"),
+		newTestRawSpan("b", "a"),
+	}
+
+	tree := newSpanTree("root", spans)
+
+	assert.Equal(t, 0, tree.depths["root"])
+	assert.Equal(t, 1, tree.children["a"])
+	assert.Equal(t, 1, tree.children["b"])
+}
+
+func BenchmarkSpanDataJSON(b *testing.B) {
+	wrapper := benchmarkSpanData()
+	b.ReportAllocs()
+	var size int
+	for i := 0; i < b.N; i++ {
+		data, err := json.Marshal(wrapper)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size = len(data)
+	}
+	b.ReportMetric(float64(size), "encoded-bytes")
+}
+
+func BenchmarkSpanDataProtobuf(b *testing.B) {
+	wrapper := benchmarkSpanData()
+	b.ReportAllocs()
+	var size int
+	for i := 0; i < b.N; i++ {
+		size = len(wrapper.MarshalProto(nil))
+	}
+	b.ReportMetric(float64(size), "encoded-bytes")
+}
+
+func BenchmarkSpanDataProtobufReusedBuffer(b *testing.B) {
+	wrapper := benchmarkSpanData()
+	b.ReportAllocs()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		buf = wrapper.MarshalProto(buf[:0])
+	}
+	b.ReportMetric(float64(len(buf)), "encoded-bytes")
+}
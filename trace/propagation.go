@@ -0,0 +1,51 @@
+package trace
+
+import (
+	"encoding/json"
+	"net/http"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+)
+
+// httpTriggerEvent holds the fields shared by API Gateway and ALB events
+// which are needed to extract an incoming span context
+type httpTriggerEvent struct {
+	RequestContext    json.RawMessage     `json:"requestContext"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+}
+
+// extractParentSpanContext returns the span context propagated through the
+// headers of an API Gateway or ALB request if there is any
+func extractParentSpanContext(request json.RawMessage, format tracer.PropagationFormat) (tracer.SpanContext, bool) {
+	e := httpTriggerEvent{}
+	if err := json.Unmarshal(request, &e); err != nil || len(e.RequestContext) == 0 {
+		return tracer.SpanContext{}, false
+	}
+	if len(e.Headers) == 0 && len(e.MultiValueHeaders) == 0 {
+		return tracer.SpanContext{}, false
+	}
+
+	headers := http.Header{}
+	for k, v := range e.MultiValueHeaders {
+		if len(v) > 0 {
+			headers.Set(k, v[0])
+		}
+	}
+	for k, v := range e.Headers {
+		headers.Set(k, v)
+	}
+
+	carrier := opentracing.HTTPHeadersCarrier(headers)
+	sc, err := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, carrier)
+	if err != nil {
+		// Fall back to the standard headers of the configured propagation format
+		sc, err = tracer.ExtractSpanContext(format, carrier)
+		if err != nil {
+			return tracer.SpanContext{}, false
+		}
+	}
+	parentCtx, ok := sc.(tracer.SpanContext)
+	return parentCtx, ok
+}
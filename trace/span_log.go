@@ -0,0 +1,96 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+// prepareSpanLogs converts the log records of a span to span logs. Each field of a record is
+// a span log named with the key of the field. The logs are keyed by their order in the span.
+func prepareSpanLogs(records []opentracing.LogRecord) map[string]spanLog {
+	logs := make(map[string]spanLog, len(records))
+	for _, record := range records {
+		encoder := &spanLogEncoder{timestamp: utils.TimeToMs(record.Timestamp)}
+		for _, field := range record.Fields {
+			field.Marshal(encoder)
+		}
+		for _, l := range encoder.logs {
+			logs[strconv.Itoa(len(logs))] = l
+		}
+	}
+	return logs
+}
+
+// spanLogEncoder collects the fields of a log record keeping the types of their values
+type spanLogEncoder struct {
+	timestamp int64
+	logs      []spanLog
+}
+
+func (e *spanLogEncoder) emit(key string, value interface{}) {
+	e.logs = append(e.logs, spanLog{Name: key, Value: value, Timestamp: e.timestamp})
+}
+
+func (e *spanLogEncoder) EmitString(key, value string) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitBool(key string, value bool) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitInt(key string, value int) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitInt32(key string, value int32) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitInt64(key string, value int64) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitUint32(key string, value uint32) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitUint64(key string, value uint64) {
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitFloat32(key string, value float32) {
+	e.EmitFloat64(key, float64(value))
+}
+
+// EmitFloat64 emits NaN and infinite values as strings since they can not be represented in JSON
+func (e *spanLogEncoder) EmitFloat64(key string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		e.emit(key, fmt.Sprint(value))
+		return
+	}
+	e.emit(key, value)
+}
+
+// EmitObject emits the values which can not be serialized to JSON in their string form
+func (e *spanLogEncoder) EmitObject(key string, value interface{}) {
+	if err, ok := value.(error); ok {
+		e.emit(key, err.Error())
+		return
+	}
+	if _, err := json.Marshal(value); err != nil {
+		e.emit(key, fmt.Sprintf("%+v", value))
+		return
+	}
+	e.emit(key, value)
+}
+
+func (e *spanLogEncoder) EmitLazyLogger(value log.LazyLogger) {
+	value(e)
+}
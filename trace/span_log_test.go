@@ -0,0 +1,60 @@
+package trace
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepareSpanLogs(t *testing.T) {
+	first := time.Unix(1600000000, 0)
+	second := first.Add(10 * time.Millisecond)
+	records := []opentracing.LogRecord{
+		{Timestamp: first, Fields: []log.Field{
+			log.String("event", "retry"),
+			log.Int("attempt", 2),
+			log.Bool("throttled", true),
+			log.Float64("backoff", 0.5),
+		}},
+		{Timestamp: second, Fields: []log.Field{
+			log.String("event", "error"),
+			log.Error(errors.New("connection reset")),
+			log.Object("request", map[string]interface{}{"table": "users"}),
+		}},
+	}
+
+	logs := prepareSpanLogs(records)
+
+	assert.Equal(t, map[string]spanLog{
+		"0": {Name: "event", Value: "retry", Timestamp: 1600000000000},
+		"1": {Name: "attempt", Value: 2, Timestamp: 1600000000000},
+		"2": {Name: "throttled", Value: true, Timestamp: 1600000000000},
+		"3": {Name: "backoff", Value: 0.5, Timestamp: 1600000000000},
+		"4": {Name: "event", Value: "error", Timestamp: 1600000000010},
+		"5": {Name: "error.object", Value: "connection reset", Timestamp: 1600000000010},
+		"6": {Name: "request", Value: map[string]interface{}{"table": "users"}, Timestamp: 1600000000010},
+	}, logs)
+}
+
+func TestPrepareSpanLogsUnserializableValues(t *testing.T) {
+	records := []opentracing.LogRecord{
+		{Timestamp: time.Unix(1600000000, 0), Fields: []log.Field{
+			log.Float64("ratio", math.NaN()),
+			log.Object("channel", make(chan int)),
+			log.Lazy(func(fv log.Encoder) {
+				fv.EmitString("lazy", "value")
+			}),
+		}},
+	}
+
+	logs := prepareSpanLogs(records)
+
+	assert.Equal(t, "NaN", logs["0"].Value)
+	assert.IsType(t, "", logs["1"].Value)
+	assert.Equal(t, spanLog{Name: "lazy", Value: "value", Timestamp: 1600000000000}, logs["2"])
+}
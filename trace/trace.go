@@ -10,9 +10,11 @@ import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
@@ -68,6 +70,11 @@ func (tr *tracePlugin) IsEnabled() bool {
 	return !config.TraceDisabled
 }
 
+// IsEnabledWith returns whether the plugin is enabled with the settings of the agent
+func (tr *tracePlugin) IsEnabledWith(s *config.Settings) bool {
+	return !s.TraceDisabled
+}
+
 func (tr *tracePlugin) Order() uint8 {
 	return pluginOrder
 }
@@ -75,10 +82,19 @@ func (tr *tracePlugin) Order() uint8 {
 // BeforeExecution executes the necessary tasks before the invocation
 func (tr *tracePlugin) BeforeExecution(ctx context.Context, request json.RawMessage) context.Context {
 	invocationCount++
+	s := config.SettingsFromContext(ctx)
 
 	startTimeInMs, ctx := plugin.StartTimeFromContext(ctx)
 	startTime := utils.MsToTime(startTimeInMs)
-	rootSpan, ctx := opentracing.StartSpanFromContext(ctx, application.ApplicationName, opentracing.StartTime(startTime))
+	spanOptions := []opentracing.StartSpanOption{opentracing.StartTime(startTime)}
+	// Continue the upstream trace if the request carries a span context
+	if parentCtx, ok := extractParentSpanContext(request, tracer.PropagationFormatOf(s)); ok {
+		plugin.TraceID = parentCtx.TraceID
+		spanOptions = append(spanOptions, opentracing.ChildOf(parentCtx))
+	}
+	rootSpan, ctx := opentracing.StartSpanFromContext(ctx, application.ApplicationName, spanOptions...)
+	// The spans of the invocation follow the settings of the agent
+	tracer.SetAgentSettings(rootSpan, s, tracer.SpanListenersFromContext(ctx))
 	tr.RootSpan = rootSpan
 
 	tr.Data = &Data{
@@ -109,6 +125,7 @@ func (tr *tracePlugin) BeforeExecution(ctx context.Context, request json.RawMess
 
 // AfterExecution executes the necessary tasks after the invocation
 func (tr *tracePlugin) AfterExecution(ctx context.Context, request json.RawMessage, response interface{}, err interface{}) ([]plugin.MonitoringDataWrapper, context.Context) {
+	s := config.SettingsFromContext(ctx)
 	finishTime, ctx := plugin.EndTimeFromContext(ctx)
 	tr.Data.FinishTime = finishTime
 	tr.Data.Duration = tr.Data.FinishTime - tr.Data.StartTime
@@ -120,10 +137,10 @@ func (tr *tracePlugin) AfterExecution(ctx context.Context, request json.RawMessa
 	// Disable request data sending for cloudwatchlog, firehose and kinesis if not
 	// enabled by configuration because requests can get too big for these
 	enableRequestData := true
-	if (config.TraceRequestDisabled) ||
-		(plugin.TriggerClassName == constants.ClassNames["KINESIS"] && !config.TraceKinesisRequestEnabled) ||
-		(plugin.TriggerClassName == constants.ClassNames["FIREHOSE"] && !config.TraceFirehoseRequestEnabled) ||
-		(plugin.TriggerClassName == constants.ClassNames["CLOUDWATCHLOG"] && !config.TraceCloudwatchlogRequestEnabled) {
+	if (s.TraceRequestDisabled) ||
+		(plugin.TriggerClassName == constants.ClassNames["KINESIS"] && !s.TraceKinesisRequestEnabled) ||
+		(plugin.TriggerClassName == constants.ClassNames["FIREHOSE"] && !s.TraceFirehoseRequestEnabled) ||
+		(plugin.TriggerClassName == constants.ClassNames["CLOUDWATCHLOG"] && !s.TraceCloudwatchlogRequestEnabled) {
 		enableRequestData = false
 	}
 	if enableRequestData {
@@ -150,7 +167,7 @@ func (tr *tracePlugin) AfterExecution(ctx context.Context, request json.RawMessa
 		}
 	}
 
-	if !config.TraceResponseDisabled {
+	if !s.TraceResponseDisabled {
 		// TODO: Serialize response properly
 		tr.RootSpan.SetTag(constants.AwsLambdaInvocationResponse, response)
 	}
@@ -180,16 +197,24 @@ func (tr *tracePlugin) AfterExecution(ctx context.Context, request json.RawMessa
 
 	spanList := tr.Recorder.GetSpans()
 
+	if s.XRayEnabled {
+		sendXRaySubsegments(ctx, tr.RootSpan.Context().(tracer.SpanContext).SpanID, spanList)
+	}
+
 	sampled := true
-	sampler := GetSampler()
-	if len(spanList) > 0 && sampler != nil {
+	sampler := samplers.FromContext(ctx, spanType, GetSampler())
+	if priority, ok := tr.upstreamSamplingPriority(); ok {
+		// Respect the sampling decision of the caller propagated with the trace context
+		sampled = priority > 0
+	} else if len(spanList) > 0 && sampler != nil {
 		sampled = sampler.IsSampled(spanList[0])
 	}
 	// Prepare report data
 	var traceArr []plugin.MonitoringDataWrapper
 	if sampled {
-		for _, s := range spanList {
-			sd := tr.prepareSpanDataModel(ctx, s)
+		tree := newSpanTree(tr.RootSpan.Context().(tracer.SpanContext).SpanID, spanList)
+		for _, span := range spanList {
+			sd := tr.prepareSpanDataModel(ctx, span, tree)
 			traceArr = append(traceArr, plugin.WrapMonitoringData(sd, spanType))
 		}
 	}
@@ -200,6 +225,17 @@ func (tr *tracePlugin) AfterExecution(ctx context.Context, request json.RawMessa
 	return traceArr, ctx
 }
 
+// upstreamSamplingPriority returns the sampling priority set on the root span
+// when the invocation continues a trace started by a caller
+func (tr *tracePlugin) upstreamSamplingPriority() (uint16, bool) {
+	rawRootSpan, ok := tracer.GetRaw(tr.RootSpan)
+	if !ok || rawRootSpan.Tags == nil {
+		return 0, false
+	}
+	priority, ok := rawRootSpan.Tags[string(ext.SamplingPriority)].(uint16)
+	return priority, ok
+}
+
 func (tr *tracePlugin) finishRootSpan() {
 	defer func() {
 		if r := recover(); r != nil {
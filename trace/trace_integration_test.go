@@ -9,13 +9,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-lambda-go/events"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/agent"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/samplers"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
 
@@ -317,6 +321,133 @@ func TestTimeout(t *testing.T) {
 
 }
 
+func TestTraceWithIncomingSpanContext(t *testing.T) {
+	config.ReportRestCompositeDataEnabled = false
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	r := test.NewMockReporter()
+	tr := New()
+	a := agent.New().AddPlugin(tr).SetReporter(r)
+	lambdaHandler := a.Wrap(func(ctx context.Context, e events.APIGatewayProxyRequest) (string, error) {
+		return "ok", nil
+	})
+	h := lambdaHandler.(func(context.Context, json.RawMessage) (interface{}, error))
+	input := `{
+		"httpMethod": "GET",
+		"requestContext": {"stage": "dev"},
+		"headers": {
+			"X-Thundra-Trace-Id": "upstream-trace",
+			"X-Thundra-Transaction-Id": "upstream-transaction",
+			"X-Thundra-Span-Id": "upstream-span"
+		}
+	}`
+	h(context.TODO(), []byte(input))
+
+	msg, err := getRootSpanData(r.MessageQueue)
+	assert.Nil(t, err)
+	rsd, ok := msg.Data.(spanDataModel)
+	assert.True(t, ok)
+	assert.Equal(t, "upstream-trace", rsd.TraceID)
+	assert.Equal(t, "upstream-span", rsd.ParentSpanID)
+	assert.NotEqual(t, "upstream-transaction", rsd.TransactionID)
+}
+
+func TestTraceWithB3NotSampled(t *testing.T) {
+	config.ReportRestCompositeDataEnabled = false
+	config.TracePropagationFormat = "b3"
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+	defer func() { config.TracePropagationFormat = constants.DefaultTracePropagationFormat }()
+
+	r := test.NewMockReporter()
+	tr := New()
+	a := agent.New().AddPlugin(tr).SetReporter(r)
+	lambdaHandler := a.Wrap(func(ctx context.Context, e events.APIGatewayProxyRequest) (string, error) {
+		return "ok", nil
+	})
+	h := lambdaHandler.(func(context.Context, json.RawMessage) (interface{}, error))
+	input := `{
+		"httpMethod": "GET",
+		"requestContext": {"stage": "dev"},
+		"headers": {
+			"X-B3-TraceId": "463ac35c9f6413ad48485a3953bb6124",
+			"X-B3-SpanId": "a2fb4a1d1a96d312",
+			"X-B3-Sampled": "0"
+		}
+	}`
+	h(context.TODO(), []byte(input))
+
+	_, err := getRootSpanData(r.MessageQueue)
+	assert.NotNil(t, err)
+}
+
+func TestTraceWithW3CSampledUsesSampler(t *testing.T) {
+	config.ReportRestCompositeDataEnabled = false
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	r := test.NewMockReporter()
+	tr := New()
+	a := agent.New(agent.WithTraceSampler(samplers.NewErrorAwareSampler())).AddPlugin(tr).SetReporter(r)
+	lambdaHandler := a.Wrap(func(ctx context.Context, e events.APIGatewayProxyRequest) (string, error) {
+		return "ok", nil
+	})
+	h := lambdaHandler.(func(context.Context, json.RawMessage) (interface{}, error))
+	// The sampled flag is set by the default sampler of OpenTelemetry for every trace
+	input := `{
+		"httpMethod": "GET",
+		"requestContext": {"stage": "dev"},
+		"headers": {
+			"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		}
+	}`
+	h(context.TODO(), []byte(input))
+
+	_, err := getRootSpanData(r.MessageQueue)
+	assert.NotNil(t, err)
+}
+
+func TestTraceFollowsAgentSettings(t *testing.T) {
+	config.ReportRestCompositeDataEnabled = false
+	test.PrepareEnvironment()
+	defer test.CleanEnvironment()
+
+	r := test.NewMockReporter()
+	tr := New()
+	a := agent.New(agent.WithSettings(func(s *config.Settings) {
+		s.TracePropagationFormat = "b3"
+		s.MaskHTTPBody = true
+	})).AddPlugin(tr).SetReporter(r)
+	var childSettings *config.Settings
+	lambdaHandler := a.Wrap(func(ctx context.Context, e events.APIGatewayProxyRequest) (string, error) {
+		span, _ := opentracing.StartSpanFromContext(ctx, "child")
+		defer span.Finish()
+		raw, _ := tracer.GetRaw(span)
+		childSettings = raw.Settings()
+		return "ok", nil
+	})
+	h := lambdaHandler.(func(context.Context, json.RawMessage) (interface{}, error))
+	input := `{
+		"httpMethod": "GET",
+		"requestContext": {"stage": "dev"},
+		"headers": {
+			"X-B3-TraceId": "463ac35c9f6413ad48485a3953bb6124",
+			"X-B3-SpanId": "a2fb4a1d1a96d312"
+		}
+	}`
+	h(context.TODO(), []byte(input))
+
+	// The spans started in the handler follow the settings of the agent, the global settings are not changed
+	assert.True(t, childSettings.MaskHTTPBody)
+	assert.False(t, config.MaskHTTPBody)
+	msg, err := getRootSpanData(r.MessageQueue)
+	assert.Nil(t, err)
+	rsd, ok := msg.Data.(spanDataModel)
+	assert.True(t, ok)
+	assert.Equal(t, "463ac35c-9f64-13ad-4848-5a3953bb6124", rsd.TraceID)
+}
+
 func getRootSpanData(monitoringDataWrappers []plugin.MonitoringDataWrapper) (*plugin.MonitoringDataWrapper, error) {
 	for _, m := range monitoringDataWrappers {
 		if m.Type == spanType {
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/agent"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/test"
 )
 
@@ -120,6 +121,15 @@ func TestSpanTransformation(t *testing.T) {
 				assert.Equal(t, "f2", f2Span.OperationName)
 				assert.True(t, f1Span.Duration >= f1Duration)
 				assert.True(t, f2Span.Duration >= f2Duration)
+
+				// Spans are ordered by their start in the invocation below the root span
+				assert.Equal(t, int64(1), rsd.SpanOrder)
+				assert.Equal(t, int64(2), f1Span.SpanOrder)
+				assert.Equal(t, int64(3), f2Span.SpanOrder)
+				assert.Equal(t, 1, tags[constants.SpanTags["DEPTH"]])
+				assert.Equal(t, 2, tags[constants.SpanTags["CHILDREN_COUNT"]])
+				assert.Equal(t, 2, f1Span.Tags[constants.SpanTags["DEPTH"]])
+				assert.Equal(t, 0, f1Span.Tags[constants.SpanTags["CHILDREN_COUNT"]])
 			}
 
 		})
@@ -0,0 +1,179 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	logger "log"
+	"net"
+	"strings"
+
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+// xrayDaemonHeader is sent before each document in the X-Ray daemon UDP protocol
+const xrayDaemonHeader = "{\"format\": \"json\", \"version\": 1}\n"
+
+type xraySubsegment struct {
+	Name        string                 `json:"name"`
+	ID          string                 `json:"id"`
+	TraceID     string                 `json:"trace_id"`
+	ParentID    string                 `json:"parent_id"`
+	Type        string                 `json:"type"`
+	StartTime   float64                `json:"start_time"`
+	EndTime     float64                `json:"end_time"`
+	Namespace   string                 `json:"namespace,omitempty"`
+	Error       bool                   `json:"error,omitempty"`
+	Fault       bool                   `json:"fault,omitempty"`
+	Throttle    bool                   `json:"throttle,omitempty"`
+	Cause       *xrayCause             `json:"cause,omitempty"`
+	HTTP        *xrayHTTP              `json:"http,omitempty"`
+	AWS         map[string]interface{} `json:"aws,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+}
+
+type xrayCause struct {
+	Exceptions []xrayException `json:"exceptions"`
+}
+
+type xrayException struct {
+	ID      string `json:"id"`
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type xrayHTTP struct {
+	Request  *xrayHTTPRequest  `json:"request,omitempty"`
+	Response *xrayHTTPResponse `json:"response,omitempty"`
+}
+
+type xrayHTTPRequest struct {
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+type xrayHTTPResponse struct {
+	Status int `json:"status,omitempty"`
+}
+
+// sendXRaySubsegments sends the given spans to the X-Ray daemon as subsegments
+// of the facade segment which is created by Lambda for the invocation
+func sendXRaySubsegments(ctx context.Context, rootSpanID string, spans []*tracer.RawSpan) {
+	traceID, segmentID := utils.GetXRayTraceInfo(ctx)
+	if traceID == "" || segmentID == "" || !utils.IsXRaySampled(ctx) {
+		return
+	}
+
+	conn, err := net.Dial("udp", config.SettingsFromContext(ctx).XRayDaemonAddress)
+	if err != nil {
+		logger.Println("Error while connecting to the X-Ray daemon:", err)
+		return
+	}
+	defer conn.Close()
+
+	for _, s := range spans {
+		parentID := segmentID
+		if s.Context.SpanID != rootSpanID {
+			if s.ParentSpanID != "" {
+				parentID = utils.ToW3CSpanID(s.ParentSpanID)
+			} else {
+				parentID = utils.ToW3CSpanID(rootSpanID)
+			}
+		}
+		b, err := json.Marshal(newXRaySubsegment(s, traceID, parentID))
+		if err != nil {
+			logger.Println("Error while marshalling the X-Ray subsegment:", err)
+			continue
+		}
+		if _, err := conn.Write(append([]byte(xrayDaemonHeader), b...)); err != nil {
+			logger.Println("Error while sending the X-Ray subsegment:", err)
+		}
+	}
+}
+
+func newXRaySubsegment(s *tracer.RawSpan, traceID string, parentID string) xraySubsegment {
+	endTimestamp := s.EndTimestamp
+	if endTimestamp < s.StartTimestamp {
+		endTimestamp = s.StartTimestamp
+	}
+	subsegment := xraySubsegment{
+		Name:      xraySubsegmentName(s),
+		ID:        utils.ToW3CSpanID(s.Context.SpanID),
+		TraceID:   traceID,
+		ParentID:  parentID,
+		Type:      "subsegment",
+		StartTime: float64(s.StartTimestamp) / 1000,
+		EndTime:   float64(endTimestamp) / 1000,
+		Annotations: map[string]interface{}{
+			"thundra_trace_id": s.Context.TraceID,
+			"thundra_span_id":  s.Context.SpanID,
+		},
+	}
+
+	if requestName, ok := s.GetTag(constants.AwsSDKTags["REQUEST_NAME"]).(string); ok {
+		subsegment.Namespace = "aws"
+		subsegment.AWS = map[string]interface{}{"operation": requestName}
+		if tableName, ok := s.GetTag(constants.AwsDynamoDBTags["TABLE_NAME"]).(string); ok && tableName != "" {
+			subsegment.AWS["table_name"] = tableName
+		}
+		if queueName, ok := s.GetTag(constants.AwsSQSTags["QUEUE_NAME"]).(string); ok && queueName != "" {
+			subsegment.AWS["queue_name"] = queueName
+		}
+		if bucketName, ok := s.GetTag(constants.AwsS3Tags["BUCKET_NAME"]).(string); ok && bucketName != "" {
+			subsegment.AWS["bucket_name"] = bucketName
+		}
+	} else if s.ClassName == constants.ClassNames["HTTP"] {
+		subsegment.Namespace = "remote"
+		method, _ := s.GetTag(constants.HTTPTags["METHOD"]).(string)
+		url, _ := s.GetTag(constants.HTTPTags["URL"]).(string)
+		subsegment.HTTP = &xrayHTTP{Request: &xrayHTTPRequest{Method: method, URL: url}}
+		if status, ok := s.GetTag(constants.HTTPTags["STATUS"]).(int); ok {
+			subsegment.HTTP.Response = &xrayHTTPResponse{Status: status}
+			subsegment.Throttle = status == 429
+			subsegment.Error = status >= 400 && status < 500
+			subsegment.Fault = status >= 500
+		}
+	}
+
+	if erroneous, _ := s.GetTag(constants.AwsError).(bool); erroneous {
+		if !subsegment.Error {
+			subsegment.Fault = true
+		}
+		errorKind, _ := s.GetTag(constants.AwsErrorKind).(string)
+		errorMessage, _ := s.GetTag(constants.AwsErrorMessage).(string)
+		subsegment.Cause = &xrayCause{Exceptions: []xrayException{{
+			ID:      subsegment.ID,
+			Type:    errorKind,
+			Message: errorMessage,
+		}}}
+	}
+	return subsegment
+}
+
+// xraySubsegmentName returns the name of the called service for the AWS SDK and HTTP
+// spans so that they are shown as separate nodes on the X-Ray service map
+func xraySubsegmentName(s *tracer.RawSpan) string {
+	if _, ok := s.GetTag(constants.AwsSDKTags["REQUEST_NAME"]).(string); ok {
+		if serviceName, ok := s.GetTag(constants.AwsSDKTags["SERVICE_NAME"]).(string); ok && serviceName != "" {
+			return serviceName
+		}
+		return strings.TrimPrefix(s.ClassName, "AWS-")
+	}
+	if s.ClassName == constants.ClassNames["HTTP"] {
+		if host, ok := s.GetTag(constants.HTTPTags["HOST"]).(string); ok && host != "" {
+			return host
+		}
+	}
+	name := s.OperationName
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", s.DomainName, s.ClassName)
+	}
+	// X-Ray names can be at most 200 characters long
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	return name
+}
@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+const xrayTestTraceHeader = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+func readXRaySubsegments(t *testing.T, conn net.PacketConn, count int) []xraySubsegment {
+	var subsegments []xraySubsegment
+	buf := make([]byte, 64*1024)
+	for i := 0; i < count; i++ {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if !assert.Nil(t, err) {
+			break
+		}
+		parts := strings.SplitN(string(buf[:n]), "\n", 2)
+		assert.Equal(t, `{"format": "json", "version": 1}`, parts[0])
+		var subsegment xraySubsegment
+		assert.Nil(t, json.Unmarshal([]byte(parts[1]), &subsegment))
+		subsegments = append(subsegments, subsegment)
+	}
+	return subsegments
+}
+
+func TestSendXRaySubsegments(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+	config.XRayDaemonAddress = conn.LocalAddr().String()
+
+	rootSpan := &tracer.RawSpan{
+		Context:        tracer.SpanContext{TraceID: "trace-id", SpanID: "root-span-id"},
+		OperationName:  "test-function",
+		ClassName:      constants.AwsLambdaApplicationClass,
+		StartTimestamp: 1000,
+		EndTimestamp:   1500,
+	}
+	dynamodbSpan := &tracer.RawSpan{
+		Context:        tracer.SpanContext{TraceID: "trace-id", SpanID: "dynamodb-span-id"},
+		OperationName:  "users",
+		ClassName:      constants.ClassNames["DYNAMODB"],
+		StartTimestamp: 1100,
+		EndTimestamp:   1200,
+		Tags: map[string]interface{}{
+			constants.AwsSDKTags["REQUEST_NAME"]:    "PutItem",
+			constants.AwsDynamoDBTags["TABLE_NAME"]: "users",
+		},
+	}
+	httpSpan := &tracer.RawSpan{
+		Context:        tracer.SpanContext{TraceID: "trace-id", SpanID: "http-span-id"},
+		ParentSpanID:   "dynamodb-span-id",
+		OperationName:  "example.com/users",
+		ClassName:      constants.ClassNames["HTTP"],
+		StartTimestamp: 1250,
+		EndTimestamp:   1300,
+		Tags: map[string]interface{}{
+			constants.HTTPTags["HOST"]:   "example.com",
+			constants.HTTPTags["METHOD"]: "GET",
+			constants.HTTPTags["STATUS"]: 503,
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), constants.AwsXRayTraceContextKey, xrayTestTraceHeader)
+	sendXRaySubsegments(ctx, "root-span-id", []*tracer.RawSpan{rootSpan, dynamodbSpan, httpSpan})
+
+	subsegments := readXRaySubsegments(t, conn, 3)
+	assert.Equal(t, 3, len(subsegments))
+
+	root := subsegments[0]
+	assert.Equal(t, "1-5759e988-bd862e3fe1be46a994272793", root.TraceID)
+	assert.Equal(t, "53995c3f42cd8ad8", root.ParentID)
+	assert.Equal(t, "subsegment", root.Type)
+	assert.Equal(t, 1.0, root.StartTime)
+	assert.Equal(t, 1.5, root.EndTime)
+	assert.Equal(t, "root-span-id", root.Annotations["thundra_span_id"])
+
+	dynamodb := subsegments[1]
+	assert.Equal(t, "DynamoDB", dynamodb.Name)
+	assert.Equal(t, "aws", dynamodb.Namespace)
+	assert.Equal(t, utils.ToW3CSpanID("root-span-id"), dynamodb.ParentID)
+	assert.Equal(t, "PutItem", dynamodb.AWS["operation"])
+	assert.Equal(t, "users", dynamodb.AWS["table_name"])
+
+	http := subsegments[2]
+	assert.Equal(t, "example.com", http.Name)
+	assert.Equal(t, "remote", http.Namespace)
+	assert.Equal(t, dynamodb.ID, http.ParentID)
+	assert.Equal(t, 503, http.HTTP.Response.Status)
+	assert.True(t, http.Fault)
+}
+
+func TestSendXRaySubsegmentsNotSampled(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+	config.XRayDaemonAddress = conn.LocalAddr().String()
+
+	ctx := context.WithValue(context.Background(), constants.AwsXRayTraceContextKey,
+		strings.Replace(xrayTestTraceHeader, "Sampled=1", "Sampled=0", 1))
+	sendXRaySubsegments(ctx, "root-span-id", []*tracer.RawSpan{{Context: tracer.SpanContext{SpanID: "root-span-id"}}})
+
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, _, err = conn.ReadFrom(make([]byte, 1024))
+	assert.NotNil(t, err)
+}
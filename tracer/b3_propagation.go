@@ -0,0 +1,122 @@
+package tracer
+
+import (
+	"strings"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+// b3Propagator injects and extracts SpanContext using the Zipkin B3 headers,
+// either as separate X-B3-* headers or as the single b3 header. Ids are
+// mapped the same way as the W3C Trace Context ids.
+type b3Propagator struct {
+	singleHeader bool
+}
+
+// Inject writes the B3 headers for the given span context into the carrier
+func (p *b3Propagator) Inject(spanContext ot.SpanContext, opaqueCarrier interface{}) error {
+	sc, ok := spanContext.(SpanContext)
+	if !ok || sc.TraceID == "" || sc.SpanID == "" {
+		return ot.ErrInvalidSpanContext
+	}
+	carrier, ok := opaqueCarrier.(ot.TextMapWriter)
+	if !ok {
+		return ot.ErrInvalidCarrier
+	}
+
+	traceID := utils.ToW3CTraceID(sc.TraceID)
+	spanID := utils.ToW3CSpanID(sc.SpanID)
+	// The sampling decision is left to the downstream service if the span has none
+	sampled := ""
+	if sc.Sampled != nil {
+		sampled = "0"
+		if *sc.Sampled {
+			sampled = "1"
+		}
+	}
+
+	if p.singleHeader {
+		b3 := traceID + "-" + spanID
+		if sampled != "" {
+			b3 += "-" + sampled
+		}
+		carrier.Set(constants.B3SingleHeader, b3)
+	} else {
+		carrier.Set(constants.B3TraceIDHeader, traceID)
+		carrier.Set(constants.B3SpanIDHeader, spanID)
+		if sampled != "" {
+			carrier.Set(constants.B3SampledHeader, sampled)
+		}
+	}
+	return nil
+}
+
+// Extract reads the span context from the B3 headers in the carrier. Both the
+// single and the multi header forms are accepted, the single header wins.
+func (p *b3Propagator) Extract(opaqueCarrier interface{}) (ot.SpanContext, error) {
+	carrier, ok := opaqueCarrier.(ot.TextMapReader)
+	if !ok {
+		return nil, ot.ErrInvalidCarrier
+	}
+
+	var single, traceID, spanID, sampled, flags string
+	err := carrier.ForeachKey(func(k, v string) error {
+		switch strings.ToLower(k) {
+		case constants.B3SingleHeader:
+			single = v
+		case constants.B3TraceIDHeader:
+			traceID = v
+		case constants.B3SpanIDHeader:
+			spanID = v
+		case constants.B3SampledHeader:
+			sampled = v
+		case constants.B3FlagsHeader:
+			flags = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if single != "" {
+		parts := strings.Split(strings.TrimSpace(single), "-")
+		if len(parts) < 2 {
+			// Sampling decision only, there is no context to continue
+			return nil, ot.ErrSpanContextNotFound
+		}
+		traceID, spanID = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else if flags == "1" {
+		sampled = "d"
+	}
+
+	if traceID == "" && spanID == "" {
+		return nil, ot.ErrSpanContextNotFound
+	}
+	traceID = strings.ToLower(traceID)
+	spanID = strings.ToLower(spanID)
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !isValidHex(traceID, 32) || !isValidHex(spanID, 16) {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+
+	sc := SpanContext{
+		TraceID: utils.FromW3CTraceID(traceID),
+		SpanID:  spanID,
+	}
+	switch strings.ToLower(sampled) {
+	case "1", "d", "true":
+		sc.Sampled = new(bool)
+		*sc.Sampled = true
+	case "0", "false":
+		sc.Sampled = new(bool)
+	}
+	return sc, nil
+}
@@ -0,0 +1,178 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+)
+
+// PropagationFormat is the type of the propagation formats supported
+// by the tracer in addition to the opentracing builtin formats
+type PropagationFormat string
+
+const (
+	// W3CTraceContext propagates span context using the W3C Trace Context
+	// traceparent and tracestate headers
+	W3CTraceContext PropagationFormat = "w3c"
+	// B3MultiHeader propagates span context using the Zipkin X-B3-* headers
+	B3MultiHeader PropagationFormat = "b3"
+	// B3SingleHeader propagates span context using the Zipkin b3 header
+	B3SingleHeader PropagationFormat = "b3single"
+)
+
+type propagator interface {
+	Inject(spanContext ot.SpanContext, opaqueCarrier interface{}) error
+	Extract(opaqueCarrier interface{}) (ot.SpanContext, error)
+}
+
+var textPropagator = &textMapPropagator{}
+
+var propagators = map[interface{}]propagator{
+	ot.TextMap:      textPropagator,
+	ot.HTTPHeaders:  textPropagator,
+	W3CTraceContext: &w3cPropagator{},
+	B3MultiHeader:   &b3Propagator{},
+	B3SingleHeader:  &b3Propagator{singleHeader: true},
+}
+
+// ConfiguredPropagationFormat returns the propagation format selected by
+// configuration. W3CTraceContext is returned for unknown formats.
+func ConfiguredPropagationFormat() PropagationFormat {
+	return propagationFormat(config.TracePropagationFormat)
+}
+
+// PropagationFormatOf returns the propagation format selected by the given settings.
+// W3CTraceContext is returned for unknown formats.
+func PropagationFormatOf(s *config.Settings) PropagationFormat {
+	return propagationFormat(s.TracePropagationFormat)
+}
+
+func propagationFormat(name string) PropagationFormat {
+	format := PropagationFormat(name)
+	if _, ok := propagators[format]; !ok {
+		return W3CTraceContext
+	}
+	return format
+}
+
+// InjectSpanContext writes the given span context into the carrier using the given format
+func InjectSpanContext(sc ot.SpanContext, format interface{}, carrier interface{}) error {
+	p, ok := propagators[format]
+	if !ok {
+		return ot.ErrUnsupportedFormat
+	}
+	return p.Inject(sc, carrier)
+}
+
+// ExtractSpanContext reads a span context from the carrier using the given format
+func ExtractSpanContext(format interface{}, carrier interface{}) (ot.SpanContext, error) {
+	p, ok := propagators[format]
+	if !ok {
+		return nil, ot.ErrUnsupportedFormat
+	}
+	return p.Extract(carrier)
+}
+
+// ContinueTrace makes the given span a child of the remote span context and
+// moves the current invocation to the remote trace. The sampling decision of
+// the remote span, if any, is kept as the sampling.priority tag. Spans which
+// already have a parent are left as they are. It returns whether the span is bound.
+func ContinueTrace(ots ot.Span, sc SpanContext) bool {
+	s, ok := ots.(*spanImpl)
+	if !ok || sc.TraceID == "" || sc.SpanID == "" {
+		return false
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.raw.ParentSpanID != "" {
+		return false
+	}
+
+	plugin.TraceID = sc.TraceID
+	s.setParent(sc)
+	return true
+}
+
+// ContinueTraceFromCarrier extracts a span context from the carrier using the
+// configured propagation format and binds the active span in ctx to it
+func ContinueTraceFromCarrier(ctx context.Context, carrier interface{}) bool {
+	span := ot.SpanFromContext(ctx)
+	if span == nil {
+		return false
+	}
+	format := ConfiguredPropagationFormat()
+	if raw, ok := GetRaw(span); ok {
+		format = PropagationFormatOf(raw.Settings())
+	}
+	sc, err := ExtractSpanContext(format, carrier)
+	if err != nil {
+		return false
+	}
+	return ContinueTrace(span, sc.(SpanContext))
+}
+
+// textMapPropagator injects and extracts SpanContext using
+// the Thundra headers for TextMap and HTTPHeaders carriers
+type textMapPropagator struct{}
+
+// Inject writes the given span context into the carrier
+func (p *textMapPropagator) Inject(spanContext ot.SpanContext, opaqueCarrier interface{}) error {
+	sc, ok := spanContext.(SpanContext)
+	if !ok {
+		return ot.ErrInvalidSpanContext
+	}
+	carrier, ok := opaqueCarrier.(ot.TextMapWriter)
+	if !ok {
+		return ot.ErrInvalidCarrier
+	}
+
+	carrier.Set(constants.ThundraTraceIDHeader, sc.TraceID)
+	carrier.Set(constants.ThundraTransactionIDHeader, sc.TransactionID)
+	carrier.Set(constants.ThundraSpanIDHeader, sc.SpanID)
+
+	for k, v := range sc.Baggage {
+		carrier.Set(constants.ThundraBaggagePrefix+k, v)
+	}
+	return nil
+}
+
+// Extract reads a span context from the carrier. Header names are
+// matched case insensitively since HTTP headers are canonicalized.
+func (p *textMapPropagator) Extract(opaqueCarrier interface{}) (ot.SpanContext, error) {
+	carrier, ok := opaqueCarrier.(ot.TextMapReader)
+	if !ok {
+		return nil, ot.ErrInvalidCarrier
+	}
+
+	sc := SpanContext{}
+	err := carrier.ForeachKey(func(k, v string) error {
+		switch lowerKey := strings.ToLower(k); lowerKey {
+		case constants.ThundraTraceIDHeader:
+			sc.TraceID = v
+		case constants.ThundraTransactionIDHeader:
+			sc.TransactionID = v
+		case constants.ThundraSpanIDHeader:
+			sc.SpanID = v
+		default:
+			if strings.HasPrefix(lowerKey, constants.ThundraBaggagePrefix) {
+				if sc.Baggage == nil {
+					sc.Baggage = make(map[string]string)
+				}
+				sc.Baggage[strings.TrimPrefix(lowerKey, constants.ThundraBaggagePrefix)] = v
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.TraceID == "" || sc.SpanID == "" {
+		return nil, ot.ErrSpanContextNotFound
+	}
+	return sc, nil
+}
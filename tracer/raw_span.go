@@ -3,6 +3,7 @@ package tracer
 import (
 	"strings"
 
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/ext"
@@ -14,6 +15,7 @@ import (
 type RawSpan struct {
 	Context        SpanContext
 	ParentSpanID   string
+	SpanOrder      int64
 	OperationName  string
 	StartTimestamp int64
 	EndTimestamp   int64
@@ -32,6 +34,16 @@ func (s *RawSpan) Duration() int64 {
 	return utils.GetTimestamp() - s.StartTimestamp
 }
 
+// Settings returns the settings of the agent which started the span, or the current settings
+// if the span is not a part of a trace started by an agent. The returned settings must not be changed.
+func (s *RawSpan) Settings() *config.Settings {
+	if s.Context.agent != nil {
+		return s.Context.agent.settings
+	}
+	settings := config.CurrentSettings()
+	return &settings
+}
+
 // GetTags filters the thundra tags and returns the remainings
 func (s *RawSpan) GetTags() ot.Tags {
 	ft := ot.Tags{}
@@ -6,7 +6,10 @@ import (
 	"time"
 
 	ot "github.com/opentracing/opentracing-go"
+	otext "github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 )
 
@@ -14,7 +17,7 @@ type spanImpl struct {
 	tracer     *tracerImpl
 	sync.Mutex // protects the fields below
 	raw        RawSpan
-	// The number of logs dropped because of MaxLogsPerSpan.
+	// The number of logs dropped because of MaxLogsPerSpan, which is the SpanMaxLogs setting.
 	numDroppedLogs int
 }
 
@@ -101,9 +104,22 @@ func (s *spanImpl) LogKV(keyValues ...interface{}) {
 	s.LogFields(fields...)
 }
 
+// appendLog adds the log record to the span unless the span already has MaxLogsPerSpan logs.
+// The number of the dropped logs is set as a tag of the span.
 func (s *spanImpl) appendLog(lr ot.LogRecord) {
+	maxLogs := config.SpanMaxLogs
+	if s.raw.Context.agent != nil {
+		maxLogs = s.raw.Context.agent.settings.SpanMaxLogs
+	}
+	if maxLogs >= 0 && len(s.raw.Logs) >= maxLogs {
+		s.numDroppedLogs++
+		if s.raw.Tags == nil {
+			s.raw.Tags = ot.Tags{}
+		}
+		s.raw.Tags[constants.SpanTags["DROPPED_LOGS"]] = s.numDroppedLogs
+		return
+	}
 	s.raw.Logs = append(s.raw.Logs, lr)
-	return
 }
 
 // LogFields parses parameter fields sequentially, as first one is the key and the second is it's value.
@@ -154,6 +170,36 @@ func (s *spanImpl) StartTimestamp() int64 {
 	return s.raw.StartTimestamp
 }
 
+// LogRecord adds the log record to the span keeping its timestamp, which the LogFields of
+// opentracing sets to the current time. It is used to bridge the events of other tracing APIs.
+func LogRecord(ots ot.Span, lr ot.LogRecord) {
+	s, ok := ots.(*spanImpl)
+	if !ok {
+		ots.LogFields(lr.Fields...)
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if lr.Timestamp.IsZero() {
+		lr.Timestamp = time.Now()
+	}
+	s.appendLog(lr)
+}
+
+// RemoveTags removes the tags with the given keys from the span. It is used to bridge the status
+// changes of other tracing APIs, such as the error status overridden by ok in OpenTelemetry.
+func RemoveTags(ots ot.Span, keys ...string) {
+	s, ok := ots.(*spanImpl)
+	if !ok {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	for _, key := range keys {
+		delete(s.raw.Tags, key)
+	}
+}
+
 // GetRaw casts opentracing span interface to spanImpl struct
 func GetRaw(ots ot.Span) (*RawSpan, bool) {
 	s, ok := ots.(*spanImpl)
@@ -165,6 +211,9 @@ func GetRaw(ots ot.Span) (*RawSpan, bool) {
 
 func (s *spanImpl) setParent(parentCtx SpanContext) {
 	s.raw.ParentSpanID = parentCtx.SpanID
+	if parentCtx.TraceID != "" {
+		s.raw.Context.TraceID = parentCtx.TraceID
+	}
 
 	if l := len(parentCtx.Baggage); l > 0 {
 		s.raw.Context.Baggage = make(map[string]string, l)
@@ -172,6 +221,36 @@ func (s *spanImpl) setParent(parentCtx SpanContext) {
 			s.raw.Context.Baggage[k] = v
 		}
 	}
+
+	// Keep the settings of the agent which started the parent
+	if parentCtx.agent != nil {
+		s.raw.Context.agent = parentCtx.agent
+	}
+
+	// Keep the sampling decision of the parent, see ext.SamplingPriority.
+	// It is also propagated to the downstream services by the context of the span.
+	s.raw.Context.Sampled = parentCtx.Sampled
+	if parentCtx.Sampled != nil {
+		if s.raw.Tags == nil {
+			s.raw.Tags = ot.Tags{}
+		}
+		var priority uint16
+		if *parentCtx.Sampled {
+			priority = 1
+		}
+		s.raw.Tags[string(otext.SamplingPriority)] = priority
+	}
+}
+
+// SetAgentSettings sets the settings and the span listeners of the agent which started the span.
+// They are inherited by the spans started as its children. The registered span listeners are
+// used if listeners is nil.
+func SetAgentSettings(ots ot.Span, settings *config.Settings, listeners []ThundraSpanListener) {
+	if s, ok := ots.(*spanImpl); ok && settings != nil {
+		s.Lock()
+		defer s.Unlock()
+		s.raw.Context.agent = &agentSettings{settings: settings, listeners: listeners}
+	}
 }
 
 func OnSpanStarted(ots ot.Span) {
@@ -180,8 +259,16 @@ func OnSpanStarted(ots ot.Span) {
 	}
 }
 
+// spanListeners returns the span listeners of the agent which started the span
+func (s *spanImpl) spanListeners() []ThundraSpanListener {
+	if s.raw.Context.agent != nil && s.raw.Context.agent.listeners != nil {
+		return s.raw.Context.agent.listeners
+	}
+	return s.tracer.GetSpanListeners()
+}
+
 func (s *spanImpl) onStarted() {
-	spanListeners := s.tracer.GetSpanListeners()
+	spanListeners := s.spanListeners()
 
 	for _, sl := range spanListeners {
 		s.handleOnSpanStarted(sl)
@@ -189,7 +276,7 @@ func (s *spanImpl) onStarted() {
 }
 
 func (s *spanImpl) onFinished() {
-	spanListeners := s.tracer.GetSpanListeners()
+	spanListeners := s.spanListeners()
 
 	for _, sl := range spanListeners {
 		s.handleOnSpanFinished(sl)
@@ -1,5 +1,7 @@
 package tracer
 
+import "github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+
 // SpanContext holds the basic Span metadata.
 type SpanContext struct {
 	TransactionID string
@@ -9,6 +11,16 @@ type SpanContext struct {
 	SpanID string
 	// The span's associated baggage.
 	Baggage map[string]string
+	// Sampling decision propagated by the upstream service, nil if unknown.
+	Sampled *bool
+	// Settings of the agent which started the trace, inherited by the child spans.
+	agent *agentSettings
+}
+
+// agentSettings are the settings and the span listeners of the agent which started a span
+type agentSettings struct {
+	settings  *config.Settings
+	listeners []ThundraSpanListener
 }
 
 // ForeachBaggageItem belongs to the opentracing.SpanContext interface
@@ -34,5 +46,5 @@ func (c SpanContext) WithBaggageItem(key, val string) SpanContext {
 		newBaggage[key] = val
 	}
 	// Use positional parameters so the compiler will help catch new fields.
-	return SpanContext{c.TransactionID, c.TraceID, c.SpanID, newBaggage}
+	return SpanContext{c.TransactionID, c.TraceID, c.SpanID, newBaggage, c.Sampled, c.agent}
 }
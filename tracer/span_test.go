@@ -3,7 +3,10 @@ package tracer
 import (
 	"testing"
 
+	ot "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 )
 
 func TestSetOperationName(t *testing.T) {
@@ -70,3 +73,63 @@ func TestLog(t *testing.T) {
 	assert.True(t, logFields[1].Key() == "boolKey" && logFields[1].Value() == true)
 	assert.True(t, logFields[2].Key() == "stringKey" && logFields[2].Value() == "foo")
 }
+
+func TestMaxLogsPerSpan(t *testing.T) {
+	defer func(maxLogs int) { config.SpanMaxLogs = maxLogs }(config.SpanMaxLogs)
+	config.SpanMaxLogs = 2
+	tracer, r := newTracerAndRecorder()
+
+	s := tracer.StartSpan("foo")
+	for i := 0; i < 5; i++ {
+		s.LogKV("attempt", i)
+	}
+	s.Finish()
+
+	rs := r.GetSpans()[0]
+	assert.Equal(t, 2, len(rs.Logs))
+	assert.Equal(t, 1, rs.Logs[1].Fields[0].Value())
+	assert.Equal(t, 3, rs.Tags[constants.SpanTags["DROPPED_LOGS"]])
+}
+
+func TestUnlimitedLogsPerSpan(t *testing.T) {
+	defer func(maxLogs int) { config.SpanMaxLogs = maxLogs }(config.SpanMaxLogs)
+	config.SpanMaxLogs = -1
+	tracer, r := newTracerAndRecorder()
+
+	s := tracer.StartSpan("foo")
+	for i := 0; i < 200; i++ {
+		s.LogKV("attempt", i)
+	}
+	s.Finish()
+
+	rs := r.GetSpans()[0]
+	assert.Equal(t, 200, len(rs.Logs))
+	assert.Nil(t, rs.Tags[constants.SpanTags["DROPPED_LOGS"]])
+}
+
+func TestAgentSettingsAreInheritedByChildSpans(t *testing.T) {
+	tracer, r := newTracerAndRecorder()
+	settings := config.CurrentSettings()
+	settings.SpanMaxLogs = 1
+	settings.MaskHTTPBody = !config.MaskHTTPBody
+	listener := NewTagInjectorSpanListener(map[string]interface{}{"tags": map[string]interface{}{"team": "payments"}})
+
+	root := tracer.StartSpan("root")
+	SetAgentSettings(root, &settings, []ThundraSpanListener{listener})
+	child := tracer.StartSpan("child", ot.ChildOf(root.Context()))
+	OnSpanStarted(child)
+	child.LogKV("attempt", 1)
+	child.LogKV("attempt", 2)
+	child.Finish()
+	root.Finish()
+
+	rs := r.GetSpans()[1]
+	assert.Equal(t, &settings, rs.Settings())
+	assert.Equal(t, "payments", rs.Tags["team"])
+	assert.Equal(t, 1, len(rs.Logs))
+	// Spans which are not a part of the trace follow the current settings
+	other := tracer.StartSpan("other")
+	other.Finish()
+	assert.Equal(t, config.MaskHTTPBody, r.GetSpans()[2].Settings().MaskHTTPBody)
+	assert.Nil(t, r.GetSpans()[2].Tags["team"])
+}
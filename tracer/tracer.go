@@ -1,8 +1,9 @@
 package tracer
 
 import (
+	"sync"
+
 	ot "github.com/opentracing/opentracing-go"
-	"github.com/pkg/errors"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/ext"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
@@ -19,6 +20,10 @@ func New(recorder SpanRecorder) ot.Tracer {
 
 type tracerImpl struct {
 	Recorder SpanRecorder
+
+	orderLock     sync.Mutex
+	transactionID string
+	spanOrder     int64
 }
 
 // StartSpan starts a new span with options and returns it.
@@ -40,6 +45,7 @@ func (t *tracerImpl) StartSpanWithOptions(operationName string, opts ot.StartSpa
 	newSpan.raw.Context.TransactionID = plugin.TransactionID
 	newSpan.raw.Context.TraceID = plugin.TraceID
 	newSpan.raw.Context.SpanID = utils.GenerateNewID()
+	newSpan.raw.SpanOrder = t.nextSpanOrder(newSpan.raw.Context.TransactionID)
 
 	for _, ref := range opts.References {
 		if ref.Type == ot.ChildOfRef {
@@ -83,18 +89,34 @@ func (t *tracerImpl) StartSpanWithOptions(operationName string, opts ot.StartSpa
 	return newSpan
 }
 
+// nextSpanOrder returns the order of the next span started in the transaction with the given ID.
+// The first span of a transaction, which is the root span of the invocation, has the order 0.
+func (t *tracerImpl) nextSpanOrder(transactionID string) int64 {
+	t.orderLock.Lock()
+	defer t.orderLock.Unlock()
+	if transactionID != t.transactionID {
+		t.transactionID = transactionID
+		t.spanOrder = 0
+	}
+	order := t.spanOrder
+	t.spanOrder++
+	return order
+}
+
 func (t *tracerImpl) getSpan() *spanImpl {
 	return &spanImpl{}
 }
 
-// TODO Will be implemented
+// Inject writes the given span context into the carrier. TextMap, HTTPHeaders
+// W3CTraceContext, B3MultiHeader and B3SingleHeader formats are supported.
 func (t *tracerImpl) Inject(sc ot.SpanContext, format interface{}, carrier interface{}) error {
-	return errors.New("Inject has not been supported yet")
+	return InjectSpanContext(sc, format, carrier)
 }
 
-// TODO Will be implemented
+// Extract reads a span context from the carrier. TextMap, HTTPHeaders
+// W3CTraceContext, B3MultiHeader and B3SingleHeader formats are supported.
 func (t *tracerImpl) Extract(format interface{}, carrier interface{}) (ot.SpanContext, error) {
-	return nil, errors.New("Extract has not been supported yet")
+	return ExtractSpanContext(format, carrier)
 }
 
 func (t *tracerImpl) AddSpanListener(listener ThundraSpanListener) {
@@ -3,13 +3,14 @@ package tracer
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
 	"log"
-	"os"
 	"strings"
 
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 )
 
@@ -29,12 +30,25 @@ func ClearSpanListeners() {
 	spanListeners = make([]ThundraSpanListener, 0)
 }
 
+type spanListenersKey struct{}
+
+// ContextWithSpanListeners returns a copy of ctx carrying the span listeners of the agent
+// which are used instead of the registered ones for the spans of its invocations
+func ContextWithSpanListeners(ctx context.Context, listeners []ThundraSpanListener) context.Context {
+	return context.WithValue(ctx, spanListenersKey{}, listeners)
+}
+
+// SpanListenersFromContext returns the span listeners carried by ctx, nil if ctx has none
+func SpanListenersFromContext(ctx context.Context) []ThundraSpanListener {
+	listeners, _ := ctx.Value(spanListenersKey{}).([]ThundraSpanListener)
+	return listeners
+}
+
 func ParseSpanListeners() {
 	ClearSpanListeners()
 
-	for _, env := range os.Environ() {
+	for _, env := range config.Environ() {
 		if strings.HasPrefix(env, constants.ThundraLambdaSpanListener) {
-			config := make(map[string]interface{})
 			splits := strings.SplitN(env, "=", 2)
 
 			if len(splits) < 2 {
@@ -44,7 +58,7 @@ func ParseSpanListeners() {
 			var err error
 			configStr := splits[1]
 
-			if !strings.HasPrefix(configStr, "{") {
+			if !strings.HasPrefix(configStr, "{") && !strings.HasPrefix(configStr, "[") {
 				configStr, err = decodeConfigStr(configStr)
 				if err != nil {
 					log.Println("Couldn't parse given span listener configuration:", err)
@@ -52,34 +66,49 @@ func ParseSpanListeners() {
 				}
 			}
 
-			if err := json.Unmarshal([]byte(configStr), &config); err != nil {
+			// A list of span listener configurations can be given in a single variable
+			var listenerDefs []map[string]interface{}
+			if strings.HasPrefix(strings.TrimSpace(configStr), "[") {
+				err = json.Unmarshal([]byte(configStr), &listenerDefs)
+			} else {
+				listenerDef := make(map[string]interface{})
+				err = json.Unmarshal([]byte(configStr), &listenerDef)
+				listenerDefs = append(listenerDefs, listenerDef)
+			}
+			if err != nil {
 				log.Println("Given span listener configuration is not a valid JSON string:", err)
 				continue
 			}
 
-			listenerName, ok := config["type"].(string)
-			if !ok {
-				log.Println("Given listener type is not a valid span listener")
-				continue
+			for _, listenerDef := range listenerDefs {
+				registerSpanListener(listenerDef)
 			}
+		}
+	}
+}
 
-			listenerConfig, ok := config["config"].(map[string]interface{})
-			if !ok {
-				log.Println("No config given for the span listener")
-			}
+func registerSpanListener(listenerDef map[string]interface{}) {
+	listenerName, ok := listenerDef["type"].(string)
+	if !ok {
+		log.Println("Given listener type is not a valid span listener")
+		return
+	}
 
-			listenerConstructor, ok := SpanListenerConstructorMap[listenerName]
-			if !ok {
-				log.Println("Given listener type is not a valid span listener")
-				continue
-			}
+	listenerConfig, ok := listenerDef["config"].(map[string]interface{})
+	if !ok {
+		log.Println("No config given for the span listener")
+	}
 
-			listener := listenerConstructor(listenerConfig)
+	listenerConstructor, ok := SpanListenerConstructorMap[listenerName]
+	if !ok {
+		log.Println("Given listener type is not a valid span listener")
+		return
+	}
 
-			if listener != nil {
-				RegisterSpanListener(listener)
-			}
-		}
+	listener := listenerConstructor(listenerConfig)
+
+	if listener != nil {
+		RegisterSpanListener(listener)
 	}
 }
 
@@ -109,4 +138,6 @@ func init() {
 	SpanListenerConstructorMap["TagInjectorSpanListener"] = NewTagInjectorSpanListener
 	SpanListenerConstructorMap["SecurityAwareSpanListener"] = NewSecurityAwareSpanListener
 	ParseSpanListeners()
+	// The span listeners given in the remote config are used after it is fetched
+	config.OnReload(ParseSpanListeners)
 }
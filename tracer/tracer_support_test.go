@@ -0,0 +1,67 @@
+package tracer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+)
+
+func TestParseSpanListenersList(t *testing.T) {
+	os.Setenv(constants.ThundraLambdaSpanListener, `[
+		{"type": "TagInjectorSpanListener", "config": {"tags": {"env": "prod"}}},
+		{"type": "LatencyInjectorSpanListener", "config": {"delay": 10}}
+	]`)
+	defer func() {
+		os.Unsetenv(constants.ThundraLambdaSpanListener)
+		ClearSpanListeners()
+	}()
+
+	ParseSpanListeners()
+
+	listeners := GetSpanListeners()
+	assert.Equal(t, 2, len(listeners))
+	assert.IsType(t, &TagInjectorSpanListener{}, listeners[0])
+	assert.IsType(t, &LatencyInjectorSpanListener{}, listeners[1])
+}
+
+func TestParseSpanListenersSingle(t *testing.T) {
+	os.Setenv(constants.ThundraLambdaSpanListener, `{"type": "TagInjectorSpanListener", "config": {"tags": {"env": "prod"}}}`)
+	defer func() {
+		os.Unsetenv(constants.ThundraLambdaSpanListener)
+		ClearSpanListeners()
+	}()
+
+	ParseSpanListeners()
+
+	assert.Equal(t, 1, len(GetSpanListeners()))
+}
+
+func TestParseSpanListenersFromRemoteConfig(t *testing.T) {
+	body := `{"thundra_agent_lambda_trace_span_listenerConfig": [
+		{"type": "TagInjectorSpanListener", "config": {"tags": {"env": "prod"}}}
+	]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	os.Setenv(constants.ThundraLambdaRemoteConfigURL, server.URL)
+	os.Setenv(constants.ThundraLambdaRemoteConfigPollInterval, "0")
+	defer func() {
+		// Clear the remote config
+		body = "{}"
+		config.PollRemoteConfig()
+		os.Unsetenv(constants.ThundraLambdaRemoteConfigURL)
+		os.Unsetenv(constants.ThundraLambdaRemoteConfigPollInterval)
+	}()
+
+	config.PollRemoteConfig()
+
+	listeners := GetSpanListeners()
+	assert.Equal(t, 1, len(listeners))
+	assert.IsType(t, &TagInjectorSpanListener{}, listeners[0])
+}
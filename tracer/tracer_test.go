@@ -1,11 +1,15 @@
 package tracer
 
 import (
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/ext"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
@@ -95,6 +99,270 @@ func TestParentChildRelation(t *testing.T) {
 	assert.True(t, parentSpan.Duration() >= 3*duration)
 }
 
+func TestSpanOrder(t *testing.T) {
+	tracer, r := newTracerAndRecorder()
+	defer func(transactionID string) { plugin.TransactionID = transactionID }(plugin.TransactionID)
+
+	plugin.TransactionID = "transaction-1"
+	parentSpan := tracer.StartSpan("parentSpan")
+	tracer.StartSpan("childSpan", opentracing.ChildOf(parentSpan.Context())).Finish()
+	tracer.StartSpan("siblingSpan", opentracing.ChildOf(parentSpan.Context())).Finish()
+	parentSpan.Finish()
+
+	spans := r.GetSpans()
+	assert.Equal(t, int64(0), spans[0].SpanOrder)
+	assert.Equal(t, int64(1), spans[1].SpanOrder)
+	assert.Equal(t, int64(2), spans[2].SpanOrder)
+
+	// The order starts over in a new transaction
+	plugin.TransactionID = "transaction-2"
+	tracer.StartSpan("nextSpan").Finish()
+	assert.Equal(t, int64(0), r.GetSpans()[3].SpanOrder)
+}
+
+func TestInjectExtract(t *testing.T) {
+	plugin.TraceID = "test-trace"
+	plugin.TransactionID = "test-transaction"
+	tracer, _ := newTracerAndRecorder()
+
+	span := tracer.StartSpan(operationName)
+	span.SetBaggageItem("user", "gandalf")
+	defer span.Finish()
+
+	for _, format := range []interface{}{opentracing.TextMap, opentracing.HTTPHeaders} {
+		carrier := opentracing.HTTPHeadersCarrier(http.Header{})
+		err := tracer.Inject(span.Context(), format, carrier)
+		assert.Nil(t, err)
+
+		extracted, err := tracer.Extract(format, carrier)
+		assert.Nil(t, err)
+
+		sc := span.Context().(SpanContext)
+		extractedSc := extracted.(SpanContext)
+		assert.Equal(t, sc.TraceID, extractedSc.TraceID)
+		assert.Equal(t, sc.TransactionID, extractedSc.TransactionID)
+		assert.Equal(t, sc.SpanID, extractedSc.SpanID)
+		assert.Equal(t, "gandalf", extractedSc.Baggage["user"])
+	}
+}
+
+func TestExtractNotFound(t *testing.T) {
+	tracer, _ := newTracerAndRecorder()
+
+	carrier := opentracing.TextMapCarrier{"foo": "bar"}
+	_, err := tracer.Extract(opentracing.TextMap, carrier)
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+
+	_, err = tracer.Extract(opentracing.Binary, carrier)
+	assert.Equal(t, opentracing.ErrUnsupportedFormat, err)
+}
+
+func TestStartSpanWithExtractedParent(t *testing.T) {
+	tracer, r := newTracerAndRecorder()
+
+	carrier := opentracing.TextMapCarrier{
+		"x-thundra-trace-id":       "upstream-trace",
+		"x-thundra-transaction-id": "upstream-transaction",
+		"x-thundra-span-id":        "upstream-span",
+	}
+	parentCtx, err := tracer.Extract(opentracing.TextMap, carrier)
+	assert.Nil(t, err)
+
+	span := tracer.StartSpan(operationName, opentracing.ChildOf(parentCtx))
+	span.Finish()
+
+	spans := r.GetSpans()
+	assert.Equal(t, "upstream-span", spans[0].ParentSpanID)
+	assert.Equal(t, "upstream-trace", spans[0].Context.TraceID)
+}
+
+func TestInjectExtractW3C(t *testing.T) {
+	plugin.TraceID = "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"
+	tracer, _ := newTracerAndRecorder()
+
+	span := tracer.StartSpan(operationName)
+	defer span.Finish()
+	sc := span.Context().(SpanContext)
+
+	carrier := opentracing.TextMapCarrier{}
+	err := tracer.Inject(span.Context(), W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+utils.ToW3CSpanID(sc.SpanID)+"-01", carrier["traceparent"])
+	assert.Equal(t, "thundra="+sc.SpanID, carrier["tracestate"])
+
+	extracted, err := tracer.Extract(W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, sc.TraceID, extracted.(SpanContext).TraceID)
+	assert.Equal(t, sc.SpanID, extracted.(SpanContext).SpanID)
+}
+
+func TestExtractW3CFromOtherVendor(t *testing.T) {
+	tracer, _ := newTracerAndRecorder()
+
+	carrier := opentracing.TextMapCarrier{
+		"Traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"Tracestate":  "congo=t61rcWkgMzE",
+	}
+	extracted, err := tracer.Extract(W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, "0af76519-16cd-43dd-8448-eb211c80319c", extracted.(SpanContext).TraceID)
+	assert.Equal(t, "b7ad6b7169203331", extracted.(SpanContext).SpanID)
+	// The sampled flag is set by default, the decision is left to the samplers
+	assert.Nil(t, extracted.(SpanContext).Sampled)
+
+	for _, traceParent := range []string{
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"00-0AF7651916CD43DD8448EB211C80319C-b7ad6b7169203331-01",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+	} {
+		_, err = tracer.Extract(W3CTraceContext, opentracing.TextMapCarrier{"traceparent": traceParent})
+		assert.Equal(t, opentracing.ErrSpanContextCorrupted, err, traceParent)
+	}
+}
+
+func TestPropagateNotSampled(t *testing.T) {
+	tracer, _ := newTracerAndRecorder()
+
+	extracted, err := tracer.Extract(W3CTraceContext, opentracing.TextMapCarrier{
+		"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+	})
+	assert.Nil(t, err)
+	assert.False(t, *extracted.(SpanContext).Sampled)
+
+	parent := tracer.StartSpan(operationName, opentracing.ChildOf(extracted))
+	defer parent.Finish()
+	span := tracer.StartSpan(operationName, opentracing.ChildOf(parent.Context()))
+	defer span.Finish()
+
+	carrier := opentracing.TextMapCarrier{}
+	err = tracer.Inject(span.Context(), W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(carrier["traceparent"], "-00"))
+	assert.Equal(t, "thundra="+span.Context().(SpanContext).SpanID+",thundra-sampled=false", carrier["tracestate"])
+
+	carrier = opentracing.TextMapCarrier{}
+	err = tracer.Inject(span.Context(), B3MultiHeader, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, "0", carrier["x-b3-sampled"])
+
+	extracted, err = tracer.Extract(B3MultiHeader, carrier)
+	assert.Nil(t, err)
+	carrier = opentracing.TextMapCarrier{}
+	err = tracer.Inject(extracted, W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(carrier["traceparent"], "-00"))
+}
+
+func TestPropagateSampled(t *testing.T) {
+	tracer, _ := newTracerAndRecorder()
+
+	extracted, err := tracer.Extract(B3MultiHeader, opentracing.TextMapCarrier{
+		"x-b3-traceid": "0af7651916cd43dd8448eb211c80319c",
+		"x-b3-spanid":  "b7ad6b7169203331",
+		"x-b3-sampled": "1",
+	})
+	assert.Nil(t, err)
+
+	span := tracer.StartSpan(operationName, opentracing.ChildOf(extracted))
+	defer span.Finish()
+
+	carrier := opentracing.TextMapCarrier{}
+	err = tracer.Inject(span.Context(), W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(carrier["traceparent"], "-01"))
+	assert.Equal(t, "thundra="+span.Context().(SpanContext).SpanID+",thundra-sampled=true", carrier["tracestate"])
+
+	extracted, err = tracer.Extract(W3CTraceContext, carrier)
+	assert.Nil(t, err)
+	assert.True(t, *extracted.(SpanContext).Sampled)
+}
+
+func TestInjectExtractB3(t *testing.T) {
+	plugin.TraceID = "4bf92f35-77b3-4da6-a3ce-929d0e0e4736"
+	tracer, _ := newTracerAndRecorder()
+
+	span := tracer.StartSpan(operationName)
+	defer span.Finish()
+	sc := span.Context().(SpanContext)
+	spanID := utils.ToW3CSpanID(sc.SpanID)
+
+	carrier := opentracing.TextMapCarrier{}
+	err := tracer.Inject(span.Context(), B3MultiHeader, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", carrier["x-b3-traceid"])
+	assert.Equal(t, spanID, carrier["x-b3-spanid"])
+	// There is no sampling decision to propagate
+	assert.NotContains(t, carrier, "x-b3-sampled")
+
+	extracted, err := tracer.Extract(B3MultiHeader, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, sc.TraceID, extracted.(SpanContext).TraceID)
+	assert.Equal(t, spanID, extracted.(SpanContext).SpanID)
+	assert.Nil(t, extracted.(SpanContext).Sampled)
+
+	carrier = opentracing.TextMapCarrier{}
+	err = tracer.Inject(span.Context(), B3SingleHeader, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736-"+spanID, carrier["b3"])
+
+	extracted, err = tracer.Extract(B3SingleHeader, carrier)
+	assert.Nil(t, err)
+	assert.Equal(t, sc.TraceID, extracted.(SpanContext).TraceID)
+	assert.Equal(t, spanID, extracted.(SpanContext).SpanID)
+}
+
+func TestExtractB3Sampling(t *testing.T) {
+	tracer, _ := newTracerAndRecorder()
+
+	extracted, err := tracer.Extract(B3MultiHeader, opentracing.TextMapCarrier{
+		"X-B3-TraceId": "463ac35c9f6413ad",
+		"X-B3-SpanId":  "a2fb4a1d1a96d312",
+		"X-B3-Sampled": "0",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000-0000-0000-463a-c35c9f6413ad", extracted.(SpanContext).TraceID)
+	assert.False(t, *extracted.(SpanContext).Sampled)
+
+	extracted, err = tracer.Extract(B3MultiHeader, opentracing.TextMapCarrier{
+		"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-d-05e3ac9a4f6e3b90",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "e457b5a2e4d86bd1", extracted.(SpanContext).SpanID)
+	assert.True(t, *extracted.(SpanContext).Sampled)
+
+	extracted, err = tracer.Extract(B3MultiHeader, opentracing.TextMapCarrier{
+		"X-B3-TraceId": "463ac35c9f6413ad",
+		"X-B3-SpanId":  "a2fb4a1d1a96d312",
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, extracted.(SpanContext).Sampled)
+
+	_, err = tracer.Extract(B3MultiHeader, opentracing.TextMapCarrier{"b3": "0"})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+
+	_, err = tracer.Extract(B3MultiHeader, opentracing.TextMapCarrier{"X-B3-TraceId": "463ac35c9f6413ad", "X-B3-SpanId": "xyz"})
+	assert.Equal(t, opentracing.ErrSpanContextCorrupted, err)
+}
+
+func TestContinueTrace(t *testing.T) {
+	tracer, _ := newTracerAndRecorder()
+	span := tracer.StartSpan(operationName)
+	defer span.Finish()
+
+	sampled := false
+	parentCtx := SpanContext{TraceID: "parent-trace", SpanID: "parent-span", Sampled: &sampled}
+	assert.True(t, ContinueTrace(span, parentCtx))
+	assert.False(t, ContinueTrace(span, SpanContext{TraceID: "other-trace", SpanID: "other-span"}))
+
+	raw, _ := GetRaw(span)
+	assert.Equal(t, "parent-trace", plugin.TraceID)
+	assert.Equal(t, "parent-trace", raw.Context.TraceID)
+	assert.Equal(t, "parent-span", raw.ParentSpanID)
+	assert.Equal(t, uint16(0), raw.Tags["sampling.priority"])
+}
+
 func newTracerAndRecorder() (opentracing.Tracer, *InMemorySpanRecorder) {
 	r := NewInMemoryRecorder()
 	tracer := New(r)
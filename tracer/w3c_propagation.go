@@ -0,0 +1,130 @@
+package tracer
+
+import (
+	"strconv"
+	"strings"
+
+	ot "github.com/opentracing/opentracing-go"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+)
+
+const w3cVersion = "00"
+const w3cSampledFlags = "01"
+const w3cNotSampledFlags = "00"
+
+// w3cPropagator injects and extracts SpanContext using the W3C Trace Context
+// headers. Trace and span ids are mapped to the W3C id formats and the
+// original span id is kept in the thundra entry of tracestate. The sampling
+// decision of the span, if there is one, is kept in the thundra-sampled entry
+// since the sampled flag of traceparent is set by default.
+type w3cPropagator struct{}
+
+// Inject writes traceparent and tracestate for the given span context into the carrier
+func (p *w3cPropagator) Inject(spanContext ot.SpanContext, opaqueCarrier interface{}) error {
+	sc, ok := spanContext.(SpanContext)
+	if !ok || sc.TraceID == "" || sc.SpanID == "" {
+		return ot.ErrInvalidSpanContext
+	}
+	carrier, ok := opaqueCarrier.(ot.TextMapWriter)
+	if !ok {
+		return ot.ErrInvalidCarrier
+	}
+
+	flags := w3cSampledFlags
+	if sc.Sampled != nil && !*sc.Sampled {
+		flags = w3cNotSampledFlags
+	}
+	traceParent := w3cVersion + "-" + utils.ToW3CTraceID(sc.TraceID) + "-" + utils.ToW3CSpanID(sc.SpanID) + "-" + flags
+	traceState := constants.W3CTraceStateThundraKey + "=" + sc.SpanID
+	if sc.Sampled != nil {
+		traceState += "," + constants.W3CTraceStateThundraSampledKey + "=" + strconv.FormatBool(*sc.Sampled)
+	}
+	carrier.Set(constants.W3CTraceParentHeader, traceParent)
+	carrier.Set(constants.W3CTraceStateHeader, traceState)
+	return nil
+}
+
+// Extract reads the span context from traceparent and tracestate in the carrier
+func (p *w3cPropagator) Extract(opaqueCarrier interface{}) (ot.SpanContext, error) {
+	carrier, ok := opaqueCarrier.(ot.TextMapReader)
+	if !ok {
+		return nil, ot.ErrInvalidCarrier
+	}
+
+	var traceParent, traceState string
+	err := carrier.ForeachKey(func(k, v string) error {
+		switch strings.ToLower(k) {
+		case constants.W3CTraceParentHeader:
+			traceParent = v
+		case constants.W3CTraceStateHeader:
+			traceState = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if traceParent == "" {
+		return nil, ot.ErrSpanContextNotFound
+	}
+
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || !isValidHex(parts[0], 2) || parts[0] == "ff" ||
+		(parts[0] == w3cVersion && len(parts) != 4) {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+	traceID, spanID := parts[1], parts[2]
+	if !isValidHex(traceID, 32) || !isValidHex(spanID, 16) || !isValidHex(parts[3], 2) {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+
+	// Prefer the original span id if the parent is a Thundra instrumented span
+	parentSpanID := spanID
+	if thundraSpanID := getTraceStateValue(traceState, constants.W3CTraceStateThundraKey); thundraSpanID != "" &&
+		utils.ToW3CSpanID(thundraSpanID) == spanID {
+		parentSpanID = thundraSpanID
+	}
+
+	sc := SpanContext{
+		TraceID: utils.FromW3CTraceID(traceID),
+		SpanID:  parentSpanID,
+	}
+	// The sampling decision is taken from the thundra-sampled entry if the upstream service made one.
+	// Otherwise an unset sampled flag drops the trace, while a set one leaves the decision to the samplers
+	// since it is also set by the services which sample every trace.
+	if sampled, err := strconv.ParseBool(getTraceStateValue(traceState, constants.W3CTraceStateThundraSampledKey)); err == nil {
+		sc.Sampled = &sampled
+	} else if flags, _ := strconv.ParseUint(parts[3], 16, 8); flags&1 == 0 {
+		sc.Sampled = new(bool)
+	}
+	return sc, nil
+}
+
+func getTraceStateValue(traceState, key string) string {
+	for _, member := range strings.Split(traceState, ",") {
+		kv := strings.SplitN(strings.TrimSpace(member), "=", 2)
+		if len(kv) == 2 && kv[0] == key {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// isValidHex checks that s is a lowercase hex string of the given length
+// which is not all zeros
+func isValidHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	nonZero := false
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+		if c != '0' {
+			nonZero = true
+		}
+	}
+	return nonZero || length == 2
+}
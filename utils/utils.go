@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -140,6 +142,44 @@ func GenerateNewID() string {
 	return uuid.New().String()
 }
 
+// ToW3CTraceID maps the given id to a 128-bit trace id in lowercase hex.
+// UUIDs are mapped to their own bytes so that FromW3CTraceID can reverse
+// the mapping, other ids are hashed.
+func ToW3CTraceID(id string) string {
+	if u, err := uuid.Parse(id); err == nil {
+		return hex.EncodeToString(u[:])
+	}
+	h := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(h[:16])
+}
+
+// ToW3CSpanID maps the given id to a 64-bit span id in lowercase hex.
+// Ids which are already in this form, such as the ones propagated by
+// other tracers, are returned as is.
+func ToW3CSpanID(id string) string {
+	if len(id) == 16 && strings.ToLower(id) == id {
+		if _, err := hex.DecodeString(id); err == nil {
+			return id
+		}
+	}
+	h := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(h[:8])
+}
+
+// FromW3CTraceID maps the given 128-bit hex trace id back to the uuid format
+// used by the agent. The id is returned as is if it is not a valid trace id.
+func FromW3CTraceID(traceID string) string {
+	b, err := hex.DecodeString(traceID)
+	if err != nil {
+		return traceID
+	}
+	u, err := uuid.FromBytes(b)
+	if err != nil {
+		return traceID
+	}
+	return u.String()
+}
+
 // GetThisProcess returns process info about this process.
 func GetThisProcess() *process.Process {
 	pid := os.Getpid()
@@ -264,7 +304,17 @@ func Round(x float64) float64 {
 
 // GetXRayTraceInfo parses X-Ray trace information
 func GetXRayTraceInfo(ctx context.Context) (string, string) {
-	traceID, segmentID := "", ""
+	traceHeader := parseXRayTraceHeader(ctx)
+	return traceHeader["Root"], traceHeader["Parent"]
+}
+
+// IsXRaySampled returns whether the X-Ray trace of the invocation is sampled
+func IsXRaySampled(ctx context.Context) bool {
+	return parseXRayTraceHeader(ctx)["Sampled"] == "1"
+}
+
+func parseXRayTraceHeader(ctx context.Context) map[string]string {
+	traceHeader := map[string]string{}
 	xrayTraceHeader, ok := ctx.Value(constants.AwsXRayTraceContextKey).(string)
 	if ok && len(xrayTraceHeader) > 0 {
 		for _, traceHeaderPart := range strings.Split(xrayTraceHeader, ";") {
@@ -272,18 +322,10 @@ func GetXRayTraceInfo(ctx context.Context) (string, string) {
 			if len(traceInfo) != 2 {
 				continue
 			}
-			traceInfoKey, traceInfoVal := traceInfo[0], traceInfo[1]
-
-			switch traceInfoKey {
-			case "Root":
-				traceID = traceInfoVal
-			case "Parent":
-				segmentID = traceInfoVal
-			}
+			traceHeader[traceInfo[0]] = traceInfo[1]
 		}
 	}
-
-	return traceID, segmentID
+	return traceHeader
 }
 
 func Contains(a []interface{}, x interface{}) bool {
@@ -3,7 +3,9 @@ package apexgatewayv2
 import (
 	"context"
 	"github.com/apex/gateway/v2"
+	"github.com/opentracing/opentracing-go"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/thundra"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -22,6 +24,9 @@ func ListenAndServe(h http.Handler) error {
 
 func wrapper(h http.Handler) func(ctx context.Context, e events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 	return func(ctx context.Context, e events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		// Continue the trace of the caller if the request carries its span context
+		tracer.ContinueTraceFromCarrier(ctx, opentracing.TextMapCarrier(e.Headers))
+
 		r, err := gateway.NewRequest(ctx, e)
 		if err != nil {
 			return events.APIGatewayV2HTTPResponse{}, err
@@ -3,8 +3,6 @@ package thundraaws
 import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
-
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
@@ -136,7 +134,7 @@ func (i *athenaIntegration) beforeCall(r *request.Request, span *tracer.RawSpan)
 		tags[constants.AwsAthenaTags["REQUEST_NAMED_QUERY_IDS"]] = namedQueryIDs
 	}
 
-	if !config.MaskAthenaStatement {
+	if !span.Settings().MaskAthenaStatement {
 		if q := i.getQuery(); len(q) > 0 {
 			tags[constants.DBTags["DB_STATEMENT"]] = q
 		}
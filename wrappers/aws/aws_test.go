@@ -25,7 +25,9 @@ import (
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/trace"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 )
@@ -1268,6 +1270,30 @@ func TestSQSSendMessage(t *testing.T) {
 	tp.Reset()
 }
 
+func TestSQSSendMessageTraceInjection(t *testing.T) {
+	plugin.TraceID = utils.GenerateNewID()
+	// Initilize trace plugin to set GlobalTracer of opentracing
+	tp := trace.New()
+
+	sess := getSessionWithSqsResponse()
+	sqsc := sqs.New(sess)
+
+	params := &sqs.SendMessageInput{
+		MessageBody: aws.String("foobar"),
+		QueueUrl:    aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/test-queue"),
+	}
+
+	sqsc.SendMessage(params)
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.NotNil(t, params.MessageAttributes["traceparent"])
+	assert.Contains(t, *params.MessageAttributes["traceparent"].StringValue, utils.ToW3CSpanID(span.Context.SpanID))
+	assert.Equal(t, "thundra="+span.Context.SpanID, *params.MessageAttributes["tracestate"].StringValue)
+
+	// Clear tracer
+	tp.Reset()
+}
+
 func TestSQSSendMessageWithMaskedMessage(t *testing.T) {
 	config.MaskSQSMessage = true
 	// Initilize trace plugin to set GlobalTracer of opentracing
@@ -15,7 +15,6 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
@@ -74,7 +73,7 @@ func (i *dynamodbIntegration) beforeCall(r *request.Request, span *tracer.RawSpa
 	}
 
 	span.Tags = tags
-	if !config.MaskDynamoDBStatement {
+	if !span.Settings().MaskDynamoDBStatement {
 		if len(dynamodbInfo.Item) > 0 {
 			tags[constants.DBTags["DB_STATEMENT"]] = dynamodbInfo.Item
 		} else if len(dynamodbInfo.Key) > 0 {
@@ -82,7 +81,7 @@ func (i *dynamodbIntegration) beforeCall(r *request.Request, span *tracer.RawSpa
 		}
 	}
 
-	if config.DynamoDBTraceInjectionEnabled {
+	if span.Settings().DynamoDBTraceInjectionEnabled {
 		if operationName == "PutItem" {
 			i.injectTraceLinkOnPut(r, span)
 		} else if operationName == "UpdateItem" {
@@ -12,3 +12,6 @@ type integration interface {
 }
 
 var integrations = make(map[string]integration, 9)
+
+// maxMessageAttributes is the number of attributes that SQS and SNS accept per message
+const maxMessageAttributes = 10
@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 
 	"github.com/aws/aws-lambda-go/lambdacontext"
@@ -82,7 +81,7 @@ func (i *lambdaIntegration) beforeCall(r *request.Request, span *tracer.RawSpan)
 		constants.SpanTags["TRIGGER_CLASS_NAME"]:      constants.AwsLambdaApplicationClass,
 	}
 
-	if !config.MaskLambdaPayload && lambdaInfo.Payload != "" {
+	if !span.Settings().MaskLambdaPayload && lambdaInfo.Payload != "" {
 		tags[constants.AwsLambdaTags["INVOCATION_PAYLOAD"]] = lambdaInfo.Payload
 	}
 	if lambdaInfo.Qualifier != "" {
@@ -94,7 +93,7 @@ func (i *lambdaIntegration) beforeCall(r *request.Request, span *tracer.RawSpan)
 
 	span.Tags = tags
 
-	if !config.LambdaTraceInjectionDisabled {
+	if !span.Settings().LambdaTraceInjectionDisabled {
 		i.injectSpanIntoClientContext(r)
 	}
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 )
@@ -125,7 +124,7 @@ func (i *sesIntegration) beforeCall(r *request.Request, span *tracer.RawSpan) {
 		constants.SpanTags["TRIGGER_CLASS_NAME"]:      constants.AwsLambdaApplicationClass,
 	}
 
-	if operationName == "SendEmail" && !config.MaskSESMail {
+	if operationName == "SendEmail" && !span.Settings().MaskSESMail {
 		if sesInfo.Subject.Data != "" {
 			tags[constants.AwsSESTags["SUBJECT"]] = sesInfo.Subject
 		}
@@ -141,7 +140,7 @@ func (i *sesIntegration) beforeCall(r *request.Request, span *tracer.RawSpan) {
 		if sesInfo.TemplateArn != "" {
 			tags[constants.AwsSESTags["TEMPLATE_ARN"]] = sesInfo.TemplateArn
 		}
-		if sesInfo.TemplateData != "" && !config.MaskSESMail {
+		if sesInfo.TemplateData != "" && !span.Settings().MaskSESMail {
 			tags[constants.AwsSESTags["TEMPLATE_DATA"]] = sesInfo.TemplateData
 		}
 	}
@@ -149,7 +148,7 @@ func (i *sesIntegration) beforeCall(r *request.Request, span *tracer.RawSpan) {
 	if sesInfo.Source != "" {
 		tags[constants.AwsSESTags["SOURCE"]] = sesInfo.Source
 	}
-	if !config.MaskSESDestination {
+	if !span.Settings().MaskSESDestination {
 		if _, isArr := sesInfo.Destination.([]string); (isArr && len(sesInfo.Destination.([]string)) > 0) || !isArr {
 			tags[constants.AwsSESTags["DESTINATION"]] = sesInfo.Destination
 		}
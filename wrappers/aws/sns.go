@@ -5,9 +5,10 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
-
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
@@ -83,11 +84,38 @@ func (i *snsIntegration) beforeCall(r *request.Request, span *tracer.RawSpan) {
 
 	message := i.getSNSMessage(r)
 
-	if !config.MaskSNSMessage && message != "" {
+	if !span.Settings().MaskSNSMessage && message != "" {
 		tags[constants.AwsSNSTags["MESSAGE"]] = message
 	}
 
 	span.Tags = tags
+
+	if !span.Settings().SNSTraceInjectionDisabled {
+		i.injectSpanIntoMessageAttributes(r, span)
+	}
+}
+
+func (i *snsIntegration) injectSpanIntoMessageAttributes(r *request.Request, span *tracer.RawSpan) {
+	input, ok := r.Params.(*sns.PublishInput)
+	if !ok {
+		return
+	}
+	carrier := opentracing.TextMapCarrier{}
+	if err := tracer.InjectSpanContext(span.Context, tracer.PropagationFormatOf(span.Settings()), carrier); err != nil {
+		return
+	}
+	if len(input.MessageAttributes)+len(carrier) > maxMessageAttributes {
+		return
+	}
+	if input.MessageAttributes == nil {
+		input.MessageAttributes = make(map[string]*sns.MessageAttributeValue, len(carrier))
+	}
+	for k, v := range carrier {
+		input.MessageAttributes[k] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
 }
 
 func (i *snsIntegration) afterCall(r *request.Request, span *tracer.RawSpan) {
@@ -6,11 +6,13 @@ import (
 	"strings"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 )
 
@@ -79,11 +81,49 @@ func (i *sqsIntegration) beforeCall(r *request.Request, span *tracer.RawSpan) {
 
 	message := i.getSQSMessage(r)
 
-	if !config.MaskSQSMessage && message != "" {
+	if !span.Settings().MaskSQSMessage && message != "" {
 		tags[constants.AwsSQSTags["MESSAGE"]] = message
 	}
 
 	span.Tags = tags
+
+	if !span.Settings().SQSTraceInjectionDisabled {
+		i.injectSpanIntoMessageAttributes(r, span)
+	}
+}
+
+func (i *sqsIntegration) injectSpanIntoMessageAttributes(r *request.Request, span *tracer.RawSpan) {
+	carrier := opentracing.TextMapCarrier{}
+	if err := tracer.InjectSpanContext(span.Context, tracer.PropagationFormatOf(span.Settings()), carrier); err != nil {
+		return
+	}
+
+	switch input := r.Params.(type) {
+	case *sqs.SendMessageInput:
+		input.MessageAttributes = addSQSMessageAttributes(input.MessageAttributes, carrier)
+	case *sqs.SendMessageBatchInput:
+		for _, entry := range input.Entries {
+			if entry != nil {
+				entry.MessageAttributes = addSQSMessageAttributes(entry.MessageAttributes, carrier)
+			}
+		}
+	}
+}
+
+func addSQSMessageAttributes(attributes map[string]*sqs.MessageAttributeValue, carrier opentracing.TextMapCarrier) map[string]*sqs.MessageAttributeValue {
+	if len(attributes)+len(carrier) > maxMessageAttributes {
+		return attributes
+	}
+	if attributes == nil {
+		attributes = make(map[string]*sqs.MessageAttributeValue, len(carrier))
+	}
+	for k, v := range carrier {
+		attributes[k] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+	return attributes
 }
 
 func (i *sqsIntegration) afterCall(r *request.Request, span *tracer.RawSpan) {
@@ -35,7 +35,7 @@ func BeforeCall(span *tracer.RawSpan, req *http.Request) {
 		constants.SpanTags["TOPOLOGY_VERTEX"]:         true,
 	}
 
-	if req != nil && req.Body != nil && !config.MaskEsBody {
+	if req != nil && req.Body != nil && !span.Settings().MaskEsBody {
 		esBody, req.Body = utils.ReadRequestBody(req.Body, int(req.ContentLength))
 		tags[constants.EsTags["ES_BODY"]] = esBody
 	}
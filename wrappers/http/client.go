@@ -228,11 +228,12 @@ func beforeCall(span *tracer.RawSpan, url, method string, req *http.Request, bod
 
 	if req != nil {
 		req.Header.Add("x-thundra-span-id", span.Context.SpanID)
+		tracer.InjectSpanContext(span.Context, tracer.PropagationFormatOf(span.Settings()), opentracing.HTTPHeadersCarrier(req.Header))
 		tags[constants.SpanTags["TRACE_LINKS"]] = []string{span.Context.SpanID}
 		bodyLen = req.ContentLength
 	}
 
-	if !config.MaskHTTPBody && body != nil {
+	if !span.Settings().MaskHTTPBody && body != nil {
 		bodyRead, newReadCloser := utils.ReadRequestBody(body, int(bodyLen))
 		if req != nil {
 			req.Body = newReadCloser
@@ -256,12 +257,12 @@ func afterCall(span *tracer.RawSpan, resp *http.Response) {
 				span.OperationName = resourceNameHeader[0]
 			}
 		}
-		if !config.Http4xxErrorDisabled && resp.StatusCode >= 400 && resp.StatusCode <= 499 {
+		if !span.Settings().Http4xxErrorDisabled && resp.StatusCode >= 400 && resp.StatusCode <= 499 {
 			span.Tags[constants.AwsError] = true
 			span.Tags[constants.AwsErrorKind] = "HttpError"
 			span.Tags[constants.AwsErrorMessage] = resp.Status
 		}
-		if !config.Http5xxErrorDisabled && resp.StatusCode >= 500 && resp.StatusCode <= 599 {
+		if !span.Settings().Http5xxErrorDisabled && resp.StatusCode >= 500 && resp.StatusCode <= 599 {
 			span.Tags[constants.AwsError] = true
 			span.Tags[constants.AwsErrorKind] = "HttpError"
 			span.Tags[constants.AwsErrorMessage] = resp.Status
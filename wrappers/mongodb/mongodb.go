@@ -7,7 +7,6 @@ import (
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
@@ -106,7 +105,7 @@ func beforeCall(span *tracer.RawSpan, event *event.CommandStartedEvent) {
 		constants.SpanTags["TOPOLOGY_VERTEX"]:         true,
 	}
 
-	if !config.MaskMongoDBCommand {
+	if !span.Settings().MaskMongoDBCommand {
 		if event.Command != nil {
 			command := event.Command.String()
 			size := len(command)
@@ -0,0 +1,29 @@
+module github.com/thundra-io/thundra-lambda-agent-go/v2/wrappers/otel
+
+// go.opentelemetry.io/otel v1.44.0 requires at least go 1.25.0.
+go 1.25.0
+
+require (
+	github.com/opentracing/opentracing-go v1.2.0
+	github.com/stretchr/testify v1.11.1
+	github.com/thundra-io/thundra-lambda-agent-go/v2 v2.0.0-20261018051358-b572d08d6701
+	go.opentelemetry.io/otel v1.44.0
+	go.opentelemetry.io/otel/trace v1.44.0
+)
+
+require (
+	github.com/StackExchange/wmi v0.0.0-20190523213315-cbe66965904d // indirect
+	github.com/aws/aws-lambda-go v1.19.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-ole/go-ole v1.2.4 // indirect
+	github.com/google/uuid v1.1.2 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/rogpeppe/go-internal v1.14.1 // indirect
+	github.com/shirou/gopsutil v2.20.8+incompatible // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,236 @@
+package thundraotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/plugin"
+	thundratrace "github.com/thundra-io/thundra-lambda-agent-go/v2/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpan(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	otelTracer := NewTracerProvider().Tracer("test-library", trace.WithInstrumentationVersion("1.0.0"))
+	_, s := otelTracer.Start(context.Background(), "operation",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("foo", "bar"), attribute.Int("count", 3)))
+	s.SetAttributes(attribute.Bool("done", true))
+	s.SetName("renamed")
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.Equal(t, "renamed", span.OperationName)
+	assert.Equal(t, "test-library", span.Tags[scopeNameTag])
+	assert.Equal(t, "1.0.0", span.Tags[scopeVersionTag])
+	assert.Equal(t, "client", span.Tags["span.kind"])
+	assert.Equal(t, "bar", span.Tags["foo"])
+	assert.Equal(t, int64(3), span.Tags["count"])
+	assert.Equal(t, true, span.Tags["done"])
+	assert.NotEqual(t, int64(0), span.EndTimestamp)
+}
+
+func TestEndIsIdempotent(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	_, s := NewTracerProvider().Tracer("test").Start(context.Background(), "operation")
+	assert.True(t, s.IsRecording())
+	s.End()
+	s.End()
+	s.SetAttributes(attribute.String("foo", "bar"))
+
+	assert.False(t, s.IsRecording())
+	assert.Len(t, tp.Recorder.GetSpans(), 1)
+	assert.Nil(t, tp.Recorder.GetSpans()[0].Tags["foo"])
+}
+
+func TestEventsAndErrors(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	_, s := NewTracerProvider().Tracer("test").Start(context.Background(), "operation")
+	s.AddEvent("cache miss", trace.WithAttributes(attribute.String("key", "user-1")))
+	s.RecordError(errors.New("connection refused"))
+	s.SetStatus(codes.Error, "request failed")
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.Len(t, span.Logs, 2)
+	assert.Equal(t, "event", span.Logs[0].Fields[0].Key())
+	assert.Equal(t, "cache miss", span.Logs[0].Fields[0].Value())
+	assert.Equal(t, "user-1", span.Logs[0].Fields[1].Value())
+	assert.Equal(t, "error", span.Logs[1].Fields[0].Value())
+	assert.Equal(t, "connection refused", span.Logs[1].Fields[2].Value())
+
+	assert.Equal(t, "ERROR", span.Tags[statusCodeTag])
+	assert.Equal(t, "request failed", span.Tags[statusDescriptionTag])
+	assert.Equal(t, true, span.Tags[constants.AwsError])
+	assert.Equal(t, "errorString", span.Tags[constants.AwsErrorKind])
+	assert.Equal(t, "request failed", span.Tags[constants.AwsErrorMessage])
+}
+
+func TestStatusOkIsFinal(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	_, s := NewTracerProvider().Tracer("test").Start(context.Background(), "operation")
+	s.SetStatus(codes.Ok, "")
+	s.SetStatus(codes.Error, "request failed")
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.Equal(t, "OK", span.Tags[statusCodeTag])
+	assert.Nil(t, span.Tags[constants.AwsError])
+}
+
+func TestStatusOkOverridesError(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	_, s := NewTracerProvider().Tracer("test").Start(context.Background(), "operation")
+	s.RecordError(errors.New("connection refused"))
+	s.SetStatus(codes.Error, "request failed")
+	s.SetStatus(codes.Ok, "")
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.Equal(t, "OK", span.Tags[statusCodeTag])
+	assert.Nil(t, span.Tags[statusDescriptionTag])
+	assert.Nil(t, span.Tags[constants.AwsError])
+	assert.Nil(t, span.Tags[constants.AwsErrorKind])
+	assert.Nil(t, span.Tags[constants.AwsErrorMessage])
+	// The recorded error is still logged
+	assert.Len(t, span.Logs, 1)
+}
+
+func TestRecordErrorAfterErrorStatus(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	_, s := NewTracerProvider().Tracer("test").Start(context.Background(), "operation")
+	s.SetStatus(codes.Error, "request failed")
+	s.RecordError(errors.New("connection refused"))
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.Equal(t, true, span.Tags[constants.AwsError])
+	assert.Equal(t, "errorString", span.Tags[constants.AwsErrorKind])
+	assert.Equal(t, "request failed", span.Tags[constants.AwsErrorMessage])
+}
+
+func TestParentChildWithOpentracing(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	root, ctx := opentracing.StartSpanFromContext(context.Background(), "root")
+	ctx, otelSpan := NewTracerProvider().Tracer("test").Start(ctx, "otel")
+	child, _ := opentracing.StartSpanFromContext(ctx, "child")
+	child.Finish()
+	otelSpan.End()
+	root.Finish()
+
+	spans := tp.Recorder.GetSpans()
+	assert.Len(t, spans, 3)
+	rootSpan, otelRawSpan, childSpan := spans[0], spans[1], spans[2]
+	assert.Equal(t, rootSpan.Context.SpanID, otelRawSpan.ParentSpanID)
+	assert.Equal(t, otelRawSpan.Context.SpanID, childSpan.ParentSpanID)
+	assert.Equal(t, rootSpan.Context.TraceID, otelRawSpan.Context.TraceID)
+	assert.Equal(t, rootSpan.Context.TraceID, childSpan.Context.TraceID)
+	assert.Equal(t, otelSpan, trace.SpanFromContext(ctx))
+}
+
+func TestNewRoot(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	root, ctx := opentracing.StartSpanFromContext(context.Background(), "root")
+	_, s := NewTracerProvider().Tracer("test").Start(ctx, "otel", trace.WithNewRoot())
+	s.End()
+	root.Finish()
+
+	span := tp.Recorder.GetSpans()[1]
+	assert.Equal(t, "otel", span.OperationName)
+	assert.Equal(t, "", span.ParentSpanID)
+}
+
+func TestRemoteParent(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), remote)
+
+	_, s := NewTracerProvider().Tracer("test").Start(ctx, "otel")
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.Equal(t, "b7ad6b7169203331", span.ParentSpanID)
+	assert.Equal(t, traceID, s.SpanContext().TraceID())
+	assert.True(t, s.SpanContext().IsValid())
+	assert.True(t, s.SpanContext().IsSampled())
+	// The sampled flag leaves the decision to the samplers
+	assert.Nil(t, span.Context.Sampled)
+}
+
+func TestUnsampledRemoteParent(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	remote := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), remote)
+
+	_, s := NewTracerProvider().Tracer("test").Start(ctx, "otel")
+	s.End()
+
+	span := tp.Recorder.GetSpans()[0]
+	assert.False(t, *span.Context.Sampled)
+	assert.False(t, s.SpanContext().IsSampled())
+}
+
+func TestRemoteParentIsJoinedWithInvocation(t *testing.T) {
+	tp := thundratrace.New()
+	defer tp.Reset()
+	traceID := plugin.TraceID
+	defer func() { plugin.TraceID = traceID }()
+
+	root, ctx := opentracing.StartSpanFromContext(context.Background(), "root")
+	remoteTraceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	remoteSpanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    remoteTraceID,
+		SpanID:     remoteSpanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx = trace.ContextWithRemoteSpanContext(ctx, remote)
+
+	_, s := NewTracerProvider().Tracer("test").Start(ctx, "otel")
+	s.End()
+	root.Finish()
+
+	spans := tp.Recorder.GetSpans()
+	rootSpan, otelSpan := spans[0], spans[1]
+	assert.Equal(t, "b7ad6b7169203331", rootSpan.ParentSpanID)
+	assert.Equal(t, rootSpan.Context.SpanID, otelSpan.ParentSpanID)
+	assert.Equal(t, "0af76519-16cd-43dd-8448-eb211c80319c", rootSpan.Context.TraceID)
+	assert.Equal(t, rootSpan.Context.TraceID, otelSpan.Context.TraceID)
+	assert.Equal(t, rootSpan.Context.TraceID, plugin.TraceID)
+}
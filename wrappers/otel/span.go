@@ -0,0 +1,195 @@
+package thundraotel
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+const (
+	statusCodeTag        = "otel.status_code"
+	statusDescriptionTag = "otel.status_description"
+	linksTag             = "otel.links"
+)
+
+// span is an OpenTelemetry span backed by a Thundra span. Attributes are set as tags, events as logs,
+// and the error status as the error tags of the span.
+type span struct {
+	embedded.Span
+	span     opentracing.Span
+	provider *TracerProvider
+
+	sync.Mutex // protects the fields below
+	ended      bool
+	status     codes.Code
+	errorKind  string
+	links      []string
+}
+
+// End finishes the Thundra span
+func (s *span) End(options ...trace.SpanEndOption) {
+	s.Lock()
+	if s.ended {
+		s.Unlock()
+		return
+	}
+	s.ended = true
+	s.Unlock()
+
+	cfg := trace.NewSpanEndConfig(options...)
+	s.span.FinishWithOptions(opentracing.FinishOptions{FinishTime: cfg.Timestamp()})
+}
+
+// AddEvent adds a log with the event name and the attributes of the event to the span
+func (s *span) AddEvent(name string, options ...trace.EventOption) {
+	if !s.IsRecording() {
+		return
+	}
+	cfg := trace.NewEventConfig(options...)
+	fields := append([]log.Field{log.String("event", name)}, attributeFields(cfg.Attributes())...)
+	tracer.LogRecord(s.span, opentracing.LogRecord{Timestamp: cfg.Timestamp(), Fields: fields})
+}
+
+// AddLink adds the span context of the link to the links tag of the span
+func (s *span) AddLink(link trace.Link) {
+	if !link.SpanContext.IsValid() || !s.IsRecording() {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.links = append(s.links, link.SpanContext.TraceID().String()+"-"+link.SpanContext.SpanID().String())
+	s.span.SetTag(linksTag, append([]string{}, s.links...))
+}
+
+// IsRecording returns true until the span is ended
+func (s *span) IsRecording() bool {
+	s.Lock()
+	defer s.Unlock()
+	return !s.ended
+}
+
+// RecordError adds an error log to the span in the form of the opentracing conventions.
+// The span is marked as erroneous only when its status is set to error.
+func (s *span) RecordError(err error, options ...trace.EventOption) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	cfg := trace.NewEventConfig(options...)
+	errorKind := utils.GetErrorType(err)
+	fields := []log.Field{
+		log.String("event", "error"),
+		log.String("error.kind", errorKind),
+		log.String("message", err.Error()),
+	}
+	if cfg.StackTrace() {
+		fields = append(fields, log.String("stack", string(debug.Stack())))
+	}
+	fields = append(fields, attributeFields(cfg.Attributes())...)
+	tracer.LogRecord(s.span, opentracing.LogRecord{Timestamp: cfg.Timestamp(), Fields: fields})
+
+	s.Lock()
+	defer s.Unlock()
+	s.errorKind = errorKind
+	if s.status == codes.Error {
+		s.span.SetTag(constants.AwsErrorKind, errorKind)
+	}
+}
+
+// SpanContext returns the span context with the Thundra trace and span IDs mapped to the W3C format.
+// The span is sampled unless the trace is dropped by the sampling decision of an upstream service.
+func (s *span) SpanContext() trace.SpanContext {
+	sc, ok := s.span.Context().(tracer.SpanContext)
+	if !ok {
+		return trace.SpanContext{}
+	}
+	traceID, _ := trace.TraceIDFromHex(utils.ToW3CTraceID(sc.TraceID))
+	spanID, _ := trace.SpanIDFromHex(utils.ToW3CSpanID(sc.SpanID))
+	flags := trace.FlagsSampled
+	if sc.Sampled != nil && !*sc.Sampled {
+		flags = 0
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+	})
+}
+
+// SetStatus sets the status tags of the span. The error tags are set if the status is error.
+// As in OpenTelemetry, unset is ignored, ok overrides error and ok can not be changed.
+func (s *span) SetStatus(code codes.Code, description string) {
+	if code == codes.Unset || !s.IsRecording() {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.status == codes.Ok {
+		return
+	}
+	s.status = code
+	s.span.SetTag(statusCodeTag, strings.ToUpper(code.String()))
+	if code != codes.Error {
+		tracer.RemoveTags(s.span, statusDescriptionTag, constants.AwsError, constants.AwsErrorKind, constants.AwsErrorMessage)
+		return
+	}
+	s.span.SetTag(statusDescriptionTag, description)
+	s.span.SetTag(constants.AwsError, true)
+	if s.errorKind != "" {
+		s.span.SetTag(constants.AwsErrorKind, s.errorKind)
+	}
+	s.span.SetTag(constants.AwsErrorMessage, description)
+}
+
+// SetName sets the operation name of the span
+func (s *span) SetName(name string) {
+	if s.IsRecording() {
+		s.span.SetOperationName(name)
+	}
+}
+
+// SetAttributes sets the attributes as the tags of the span
+func (s *span) SetAttributes(kv ...attribute.KeyValue) {
+	if !s.IsRecording() {
+		return
+	}
+	for _, attr := range kv {
+		s.span.SetTag(string(attr.Key), attr.Value.AsInterface())
+	}
+}
+
+// TracerProvider returns the provider of the tracer which started the span
+func (s *span) TracerProvider() trace.TracerProvider {
+	return s.provider
+}
+
+// attributeFields converts the attributes to log fields keeping the types of their values
+func attributeFields(attributes []attribute.KeyValue) []log.Field {
+	fields := make([]log.Field, 0, len(attributes))
+	for _, kv := range attributes {
+		key := string(kv.Key)
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			fields = append(fields, log.Bool(key, kv.Value.AsBool()))
+		case attribute.INT64:
+			fields = append(fields, log.Int64(key, kv.Value.AsInt64()))
+		case attribute.FLOAT64:
+			fields = append(fields, log.Float64(key, kv.Value.AsFloat64()))
+		case attribute.STRING:
+			fields = append(fields, log.String(key, kv.Value.AsString()))
+		case attribute.INVALID:
+		default:
+			fields = append(fields, log.Object(key, kv.Value.AsInterface()))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,118 @@
+package thundraotel
+
+import (
+	"context"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	otext "github.com/opentracing/opentracing-go/ext"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
+	"github.com/thundra-io/thundra-lambda-agent-go/v2/utils"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+const (
+	scopeNameTag    = "otel.scope.name"
+	scopeVersionTag = "otel.scope.version"
+)
+
+// TracerProvider provides the OpenTelemetry tracers creating their spans with the Thundra tracer,
+// so the spans of the libraries instrumented with OpenTelemetry are reported with the spans of the
+// trace plugin. Register it with otel.SetTracerProvider(thundraotel.NewTracerProvider()).
+type TracerProvider struct {
+	embedded.TracerProvider
+}
+
+// NewTracerProvider returns a new TracerProvider
+func NewTracerProvider() *TracerProvider {
+	return &TracerProvider{}
+}
+
+// Tracer returns a tracer for the instrumentation library with the given name
+func (p *TracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	cfg := trace.NewTracerConfig(options...)
+	return &otelTracer{provider: p, name: name, version: cfg.InstrumentationVersion()}
+}
+
+type otelTracer struct {
+	embedded.Tracer
+	provider *TracerProvider
+	name     string
+	version  string
+}
+
+// Start starts a span with the global opentracing tracer which is the Thundra tracer set by the trace plugin.
+// The span is the child of the opentracing or the OpenTelemetry span in ctx, and the returned context carries
+// the span for both APIs so that the spans started by either of them afterwards are its children.
+func (t *otelTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+
+	tags := opentracing.Tags{scopeNameTag: t.name}
+	if t.version != "" {
+		tags[scopeVersionTag] = t.version
+	}
+	if kind := cfg.SpanKind(); kind != trace.SpanKindUnspecified && kind != trace.SpanKindInternal {
+		tags[string(otext.SpanKind)] = kind.String()
+	}
+	for _, kv := range cfg.Attributes() {
+		tags[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	spanOptions := []opentracing.StartSpanOption{tags}
+	if !cfg.Timestamp().IsZero() {
+		spanOptions = append(spanOptions, opentracing.StartTime(cfg.Timestamp()))
+	}
+	if !cfg.NewRoot() {
+		if parent, ok := parentSpanContext(ctx); ok {
+			spanOptions = append(spanOptions, opentracing.ChildOf(parent))
+		}
+	}
+
+	s := &span{
+		span:     opentracing.GlobalTracer().StartSpan(spanName, spanOptions...),
+		provider: t.provider,
+	}
+	for _, link := range cfg.Links() {
+		s.AddLink(link)
+	}
+
+	ctx = opentracing.ContextWithSpan(ctx, s.span)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// parentSpanContext returns the context of the opentracing or the OpenTelemetry span in ctx. A remote span context
+// of OpenTelemetry, such as the one extracted by an OpenTelemetry propagator, becomes the parent of the root span
+// of the invocation in ctx if it does not have one, so the invocation is moved to the remote trace. If ctx has no
+// Thundra span, the spans started with it continue the remote trace but are not joined with the invocation.
+func parentSpanContext(ctx context.Context) (opentracing.SpanContext, bool) {
+	sc := trace.SpanContextFromContext(ctx)
+	parent := opentracing.SpanFromContext(ctx)
+	if sc.IsValid() && sc.IsRemote() {
+		if parent == nil {
+			return remoteSpanContext(sc), true
+		}
+		tracer.ContinueTrace(parent, remoteSpanContext(sc))
+	}
+	if parent != nil {
+		return parent.Context(), true
+	}
+	if !sc.IsValid() {
+		return nil, false
+	}
+	if s, ok := trace.SpanFromContext(ctx).(*span); ok {
+		return s.span.Context(), true
+	}
+	return remoteSpanContext(sc), true
+}
+
+// remoteSpanContext converts the span context of OpenTelemetry to a Thundra span context. As with the sampled flag
+// of the W3C traceparent, an unsampled span drops the trace while a sampled one leaves the decision to the samplers.
+func remoteSpanContext(sc trace.SpanContext) tracer.SpanContext {
+	remote := tracer.SpanContext{
+		TraceID: utils.FromW3CTraceID(sc.TraceID().String()),
+		SpanID:  sc.SpanID().String(),
+	}
+	if !sc.IsSampled() {
+		remote.Sampled = new(bool)
+	}
+	return remote
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 )
@@ -65,7 +64,7 @@ func (i *mysqlIntegration) beforeCall(query string, span *tracer.RawSpan, dsn st
 		constants.DBTags["DB_PORT"]:                   port,
 	}
 
-	if !config.MaskRDBStatement {
+	if !span.Settings().MaskRDBStatement {
 		tags[constants.DBTags["DB_STATEMENT"]] = query
 	}
 
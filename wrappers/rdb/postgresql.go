@@ -9,7 +9,6 @@ import (
 	"unicode"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 )
@@ -72,7 +71,7 @@ func (i *postgresqlIntegration) beforeCall(query string, span *tracer.RawSpan, d
 		constants.DBTags["DB_PORT"]:                   port,
 	}
 
-	if !config.MaskRDBStatement {
+	if !span.Settings().MaskRDBStatement {
 		tags[constants.DBTags["DB_STATEMENT"]] = query
 	}
 
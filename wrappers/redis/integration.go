@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/application"
-	"github.com/thundra-io/thundra-lambda-agent-go/v2/config"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/constants"
 	"github.com/thundra-io/thundra-lambda-agent-go/v2/tracer"
 )
@@ -34,7 +33,7 @@ func BeforeCall(span *tracer.RawSpan, host string, port string, commandName stri
 	tags[constants.SpanTags["TRIGGER_OPERATION_NAMES"]] = []string{application.FunctionName}
 	tags[constants.SpanTags["TOPOLOGY_VERTEX"]] = true
 
-	if !config.MaskRedisCommand {
+	if !span.Settings().MaskRedisCommand {
 		tags[constants.DBTags["DB_STATEMENT"]] = command
 		tags[constants.RedisTags["REDIS_COMMAND"]] = command
 	}
@@ -43,7 +42,7 @@ func BeforeCall(span *tracer.RawSpan, host string, port string, commandName stri
 }
 
 func AfterCall(span *tracer.RawSpan, command string) {
-	if !config.MaskRedisCommand {
+	if !span.Settings().MaskRedisCommand {
 		span.Tags[constants.DBTags["DB_STATEMENT"]] = command
 		span.Tags[constants.RedisTags["REDIS_COMMAND"]] = command
 	}